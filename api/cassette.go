@@ -0,0 +1,230 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxRecordedBody is the largest body kept in a cassette, larger or binary bodies only record their size
+const maxRecordedBody = 1 << 20
+
+// redacted replaces secrets in recorded interactions
+const redacted = "REDACTED"
+
+// secretsRe matches presigned URL signatures and webhook URLs in recorded URLs and bodies
+var secretsRe = regexp.MustCompile(`(?i)((?:X-Amz-Signature|X-Amz-Credential|X-Amz-Security-Token|Signature)=)[^&"\s\\]+|(https://hooks\.slack\.com/)[^"\s\\]+`)
+
+// Interaction is a request made with a Client and the response it received, as stored in a cassette
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+	// Error is the error returned instead of a response, if any
+	Error string `json:"error,omitempty"`
+}
+
+// RecordedRequest is the redacted form of a request in a cassette
+type RecordedRequest struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     string      `json:"body,omitempty"`
+	BodySize int         `json:"body_size"`
+}
+
+// RecordedResponse is the redacted form of a response in a cassette
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodySize   int         `json:"body_size"`
+}
+
+// Recorder is a Client which writes every request made with Client, and the response to it, to a
+// cassette. Each interaction is written as a line of JSON as soon as it completes, so a cassette is
+// complete even when the program exits early. Bearer tokens and presigned URL signatures are redacted.
+type Recorder struct {
+	Client Client
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder creates a Recorder making requests with client and writing the cassette to w
+func NewRecorder(client Client, w io.Writer) *Recorder {
+	return &Recorder{Client: client, w: w}
+}
+
+// Do makes the request with the recorder's Client and records the interaction
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    redactSecrets(req.URL.String()),
+			Header: redactHeader(req.Header),
+		},
+	}
+	interaction.Request.Body, interaction.Request.BodySize = recordBody(reqBody)
+
+	res, err := r.Client.Do(req)
+	if err != nil {
+		interaction.Error = err.Error()
+		r.write(interaction)
+		return nil, err
+	}
+
+	var resBody []byte
+	if res.Body != nil {
+		resBody, err = ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+		if err != nil {
+			interaction.Error = err.Error()
+		}
+	}
+	interaction.Response = RecordedResponse{
+		StatusCode: res.StatusCode,
+		Header:     redactHeader(res.Header),
+	}
+	interaction.Response.Body, interaction.Response.BodySize = recordBody(resBody)
+
+	r.write(interaction)
+	return res, err
+}
+
+func (r *Recorder) write(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	json.NewEncoder(r.w).Encode(interaction)
+}
+
+// Replayer is a Client which responds to requests from a cassette made by a Recorder. A request is
+// answered by the first unused interaction with the same method and redacted URL.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer creates a Replayer for the recorded interactions
+func NewReplayer(interactions []Interaction) *Replayer {
+	return &Replayer{interactions: interactions, used: make([]bool, len(interactions))}
+}
+
+// LoadReplayer reads the cassette at path and creates a Replayer for it
+func LoadReplayer(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	interactions, err := ReadCassette(f)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read cassette '%s'. Err: %v", path, err)
+	}
+	return NewReplayer(interactions), nil
+}
+
+// ReadCassette reads the interactions written by a Recorder
+func ReadCassette(r io.Reader) ([]Interaction, error) {
+	interactions := []Interaction{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*maxRecordedBody)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	return interactions, scanner.Err()
+}
+
+// Do responds with the next recorded response for the request's method and URL
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url := redactSecrets(req.URL.String())
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != url {
+			continue
+		}
+		r.used[i] = true
+
+		if interaction.Error != "" && interaction.Response.StatusCode == 0 {
+			return nil, fmt.Errorf("replayed error: %s", interaction.Error)
+		}
+		header := interaction.Response.Header
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			StatusCode: interaction.Response.StatusCode,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(interaction.Response.Body)),
+			Request:    req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded response left for %s %s", req.Method, url)
+}
+
+// redactSecrets replaces presigned URL signatures and webhook paths in s
+func redactSecrets(s string) string {
+	return secretsRe.ReplaceAllStringFunc(s, func(match string) string {
+		m := secretsRe.FindStringSubmatch(match)
+		if m[1] != "" {
+			return m[1] + redacted
+		}
+		return m[2] + redacted
+	})
+}
+
+// redactHeader copies h, replacing credentials
+func redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := http.Header{}
+	for k, v := range h {
+		switch http.CanonicalHeaderKey(k) {
+		case "Authorization", "Cookie", "Set-Cookie", "X-Amz-Security-Token":
+			out[k] = []string{redacted}
+		default:
+			out[k] = append([]string{}, v...)
+		}
+	}
+	return out
+}
+
+// recordBody returns the redacted body to store, which is empty when the body is binary or too large
+func recordBody(b []byte) (string, int) {
+	if len(b) > maxRecordedBody || !utf8.Valid(b) {
+		return "", len(b)
+	}
+	return redactSecrets(string(b)), len(b)
+}
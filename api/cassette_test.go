@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const presignedResponse = `{"presigned_url":"https://bucket.s3.amazonaws.com/key.zip?X-Amz-Credential=AKIA123&X-Amz-Signature=abc123","dev_notify_url":"https://hooks.slack.com/services/T0/B0/secret"}`
+
+func Test_RecorderAndReplayer(t *testing.T) {
+	mock := MockResponses(presignedResponse, `{"status":"pending"}`, `{"status":"success"}`)
+	mock.StatusCodes = []int{200, 200, 200}
+
+	var cassette bytes.Buffer
+	recorder := NewRecorder(mock, &cassette)
+
+	req, _ := http.NewRequest("GET", "https://example.com/api/v1/users/cli_access", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	recorder.Do(req)
+
+	for i := 0; i < 2; i++ {
+		req, _ = http.NewRequest("PUT", "https://bucket.s3.amazonaws.com/key.zip?X-Amz-Signature=abc123", bytes.NewBufferString("\xff\xfe"))
+		res, _ := recorder.Do(req)
+		if res.StatusCode != 200 {
+			t.Errorf("Recorder should return the client's response, got %d", res.StatusCode)
+		}
+	}
+	sent := new(bytes.Buffer)
+	sent.ReadFrom(mock.Requests[1].Body)
+	if sent.String() != "\xff\xfe" {
+		t.Errorf("Recorder should pass the request body on to the client, got %q", sent.String())
+	}
+
+	recorded := cassette.String()
+	for _, secret := range []string{"secret-token", "abc123", "AKIA123", "services/T0"} {
+		if strings.Contains(recorded, secret) {
+			t.Errorf("cassette should not contain '%s':\n%s", secret, recorded)
+		}
+	}
+
+	interactions, err := ReadCassette(&cassette)
+	if err != nil || len(interactions) != 3 {
+		t.Fatalf("ReadCassette should read 3 interactions, got %d and %v", len(interactions), err)
+	}
+	if interactions[1].Request.Body != "" || interactions[1].Request.BodySize != 2 {
+		t.Errorf("binary bodies should only record their size, got %+v", interactions[1].Request)
+	}
+
+	replayer := NewReplayer(interactions)
+	req, _ = http.NewRequest("GET", "https://example.com/api/v1/users/cli_access", nil)
+	res, err := replayer.Do(req)
+	if err != nil || res.StatusCode != 200 {
+		t.Errorf("Replayer should respond to a recorded request, got %v", err)
+	}
+
+	// requests are matched by their redacted url, in the order they were recorded
+	for _, expected := range []string{`{"status":"pending"}`, `{"status":"success"}`} {
+		req, _ = http.NewRequest("PUT", "https://bucket.s3.amazonaws.com/key.zip?X-Amz-Signature=different", nil)
+		res, err = replayer.Do(req)
+		if err != nil {
+			t.Errorf("Replayer should respond to a recorded request, got %v", err)
+			continue
+		}
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(res.Body)
+		if buf.String() != expected {
+			t.Errorf("Replayer should respond with '%s', got '%s'", expected, buf.String())
+		}
+	}
+
+	_, err = replayer.Do(req)
+	if err == nil || !strings.Contains(err.Error(), "no recorded response left") {
+		t.Errorf("Replayer should fail once the recorded responses are used, got %v", err)
+	}
+}
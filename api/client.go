@@ -13,12 +13,14 @@ type Client interface {
 // MockClient is responsible for stubbing requests in tests
 // if the Response field is set, the mock client will respond to each request with the Response
 // If Responses is set, the mock client will match subsequent requests to subsequent responses,
-// moving along the array of Responses once for each request
+// moving along the array of Responses once for each request. StatusCodes are matched to requests
+// the same way, overriding StatusCode for any request with a non-zero code.
 type MockClient struct {
-	Response   []byte
-	Responses  [][]byte
-	Requests   []*http.Request
-	StatusCode int
+	Response    []byte
+	Responses   [][]byte
+	Requests    []*http.Request
+	StatusCode  int
+	StatusCodes []int
 }
 
 // Do saves the HTTP Request, returning 200 and no error
@@ -29,6 +31,9 @@ func (mock *MockClient) Do(req *http.Request) (*http.Response, error) {
 	if mock.StatusCode != 0 && mock.StatusCode != 200 {
 		statusCode = mock.StatusCode
 	}
+	if i := len(mock.Requests) - 1; i < len(mock.StatusCodes) && mock.StatusCodes[i] != 0 {
+		statusCode = mock.StatusCodes[i]
+	}
 
 	if len(mock.Response) > 0 {
 		return &http.Response{
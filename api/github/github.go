@@ -1,16 +1,24 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
+	"github.com/Masterminds/semver"
 	"github.com/gSchool/glearn-cli/api"
 )
 
 var API *APIClient
 
-const tagUrl = "https://api.github.com/repos/gSchool/glearn-cli/tags"
+// DefaultBaseURL is the GitHub API the CLI's releases are found on
+const DefaultBaseURL = "https://api.github.com"
+
+// repoPath is the path of the CLI's repository on the GitHub API
+const repoPath = "/repos/gSchool/glearn-cli"
 
 type tag struct {
 	Name       string `json:"name"`
@@ -25,25 +33,89 @@ type commit struct {
 	Url string `json:"url"`
 }
 
+// Release is a published release of the CLI and the files attached to it
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release, such as an archive of the CLI for one platform
+type Asset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
 type APIClient struct {
 	Client api.Client
+	// BaseURL is the GitHub API, which can be changed to test against a fake server
+	BaseURL string
 }
 
 func NewAPI(client api.Client) *APIClient {
-	return &APIClient{Client: client}
+	return &APIClient{Client: client, BaseURL: DefaultBaseURL}
+}
+
+// Asset returns the release's asset with the given name
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
 }
 
-func (api *APIClient) GetLatestVersion() (string, error) {
-	req, err := http.NewRequest(
-		"GET",
-		tagUrl,
-		nil,
-	)
+// GetLatestRelease returns the newest published release, which is never a draft or pre-release
+func (api *APIClient) GetLatestRelease(ctx context.Context) (*Release, error) {
+	res, err := api.get(ctx, api.BaseURL+repoPath+"/releases/latest")
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var release Release
+	err = json.NewDecoder(res.Body).Decode(&release)
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// Download writes the file at url, such as an asset's BrowserDownloadURL, to w
+func (api *APIClient) Download(ctx context.Context, url string, w io.Writer) error {
+	res, err := api.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+// get makes a GET request to url, returning an error unless the response is 200
+func (api *APIClient) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
 	}
 
 	res, err := api.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("Error: response status: %d from %s", res.StatusCode, url)
+	}
+	return res, nil
+}
+
+// GetLatestVersion returns the name of the newest release tag, comparing every tag as a semantic
+// version. Pre-release tags and tags which are not versions are ignored.
+func (api *APIClient) GetLatestVersion(ctx context.Context) (string, error) {
+	res, err := api.get(ctx, api.BaseURL+repoPath+"/tags?per_page=100")
 	if err != nil {
 		return "", err
 	}
@@ -56,8 +128,26 @@ func (api *APIClient) GetLatestVersion() (string, error) {
 		return "", err
 	}
 
-	if len(tags) > 0 {
-		return tags[0].Name, nil
+	return latestTag(tags)
+}
+
+// latestTag returns the name of the tag with the highest version
+func latestTag(tags []tag) (string, error) {
+	var latest *semver.Version
+	var name string
+	for _, t := range tags {
+		v, err := semver.NewVersion(t.Name)
+		if err != nil || v.Prerelease() != "" {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+			name = t.Name
+		}
+	}
+
+	if latest == nil {
+		return "", errors.New("No release tags found for the learn CLI")
 	}
-	return "", fmt.Errorf("No tags found at %s\n", tagUrl)
+	return name, nil
 }
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"context"
 	"testing"
 
 	"github.com/gSchool/glearn-cli/api"
@@ -11,7 +12,7 @@ const tagResponse = `[{"name":"v0.1.2"},{"name":"v0.1.1"}]`
 func Test_GetLatestVersion(t *testing.T) {
 	mockClient := api.MockResponse(tagResponse)
 	githubClient := NewAPI(mockClient)
-	version, err := githubClient.GetLatestVersion()
+	version, err := githubClient.GetLatestVersion(context.Background())
 	if err != nil {
 		t.Errorf("GetLatestVersion error: %s\n", err)
 	}
@@ -24,8 +25,49 @@ func Test_GetLatestVersion(t *testing.T) {
 		t.Errorf("Request made to github should be a GET, was %s", req.Method)
 	}
 
-	urlTarget := "https://api.github.com/repos/gSchool/glearn-cli/tags"
+	urlTarget := "https://api.github.com/repos/gSchool/glearn-cli/tags?per_page=100"
 	if req.URL.String() != urlTarget {
 		t.Errorf("Request made to github should be to url '%s' but was '%s'\n", urlTarget, req.URL.String())
 	}
 }
+
+func Test_GetLatestVersion_ComparesEveryTag(t *testing.T) {
+	mockClient := api.MockResponse(`[{"name":"v0.9.3"},{"name":"v0.10.14"},{"name":"v0.10.2"},{"name":"v0.11.0-beta.1"},{"name":"nightly"}]`)
+	version, err := NewAPI(mockClient).GetLatestVersion(context.Background())
+	if err != nil || version != "v0.10.14" {
+		t.Errorf("GetLatestVersion should return the highest release, got '%s' and %v", version, err)
+	}
+
+	mockClient = api.MockResponse(`{"message":"API rate limit exceeded"}`)
+	mockClient.StatusCode = 403
+	if _, err := NewAPI(mockClient).GetLatestVersion(context.Background()); err == nil {
+		t.Errorf("GetLatestVersion should fail when GitHub does not respond with tags")
+	}
+
+	mockClient = api.MockResponse(`[{"name":"nightly"}]`)
+	if _, err := NewAPI(mockClient).GetLatestVersion(context.Background()); err == nil {
+		t.Errorf("GetLatestVersion should fail without any release tags")
+	}
+}
+
+func Test_GetLatestRelease(t *testing.T) {
+	mockClient := api.MockResponse(`{"tag_name":"v0.11.0","assets":[{"name":"checksums.txt","size":12,"browser_download_url":"https://example.com/checksums.txt"}]}`)
+	githubClient := NewAPI(mockClient)
+	githubClient.BaseURL = "http://127.0.0.1:8080"
+
+	release, err := githubClient.GetLatestRelease(context.Background())
+	if err != nil || release.TagName != "v0.11.0" {
+		t.Fatalf("GetLatestRelease should return the release, got %+v and %v", release, err)
+	}
+	if asset, ok := release.Asset("checksums.txt"); !ok || asset.BrowserDownloadURL != "https://example.com/checksums.txt" {
+		t.Errorf("Asset should find the release's checksums.txt, got %+v", asset)
+	}
+	if _, ok := release.Asset("learn.zip"); ok {
+		t.Errorf("Asset should not find a missing asset")
+	}
+
+	urlTarget := "http://127.0.0.1:8080/repos/gSchool/glearn-cli/releases/latest"
+	if req := mockClient.Requests[0]; req.URL.String() != urlTarget {
+		t.Errorf("Request should be made to the configured base url '%s' but was '%s'\n", urlTarget, req.URL.String())
+	}
+}
@@ -1,18 +1,17 @@
 package learn
 
 import (
+	"context"
 	"testing"
 
 	"github.com/gSchool/glearn-cli/api"
-	"github.com/spf13/viper"
 )
 
 const validBlockResponse = `{"blocks":[{"id":1,"repo_name":"blocks-test","sync_errors":["somethin is wrong"],"title":"Blocks Test","cohorts_using":[7,9]}]}`
 
 func Test_Getters(t *testing.T) {
-	viper.Set("api_token", "apiToken")
 	mockClient := api.MockResponse(validBlockResponse)
-	API, _ := NewAPI("https://example.com", mockClient, false)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
 
 	if API.BaseURL() != "https://example.com" {
 		t.Errorf("BaseURL() should return 'https://example.com', but returned '%s'", API.BaseURL())
@@ -20,16 +19,15 @@ func Test_Getters(t *testing.T) {
 }
 
 func Test_GetBlockByRepoName(t *testing.T) {
-	viper.Set("api_token", "apiToken")
 	mockClient := api.MockResponse(validBlockResponse)
-	API, _ := NewAPI("https://example.com", mockClient, false)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
 
 	repo := RepoPieces{}
 	repo.RepoName = "blocks-test"
 	repo.Org = "gSchool"
 	repo.Origin = "github.com"
 
-	block, err := API.GetBlockByRepoName(repo)
+	block, err := API.GetBlockByRepoName(context.Background(), repo)
 	if err != nil {
 		t.Errorf("error not nil: %s\n", err)
 	}
@@ -62,16 +60,15 @@ func Test_GetBlockByRepoName(t *testing.T) {
 }
 
 func Test_CreateBlockByRepoName(t *testing.T) {
-	viper.Set("api_token", "apiToken")
 	mockClient := api.MockResponse(validBlockResponse)
-	API, _ := NewAPI("https://example.com", mockClient, false)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
 
 	repo := RepoPieces{}
 	repo.RepoName = "blocks-test"
 	repo.Org = "gSchool"
 	repo.Origin = "github.com"
 
-	block, err := API.CreateBlockByRepoName(repo)
+	block, err := API.CreateBlockByRepoName(context.Background(), repo)
 	if err != nil {
 		t.Errorf("error not nil: %s\n", err)
 	}
@@ -106,11 +103,10 @@ func Test_CreateBlockByRepoName(t *testing.T) {
 const validMasterReleaseResponse = `{"release_id":9}`
 
 func Test_CreateBranchRelease(t *testing.T) {
-	viper.Set("api_token", "apiToken")
 	mockClient := api.MockResponse(validMasterReleaseResponse)
-	API, _ := NewAPI("https://example.com", mockClient, false)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
 
-	id, err := API.CreateBranchRelease(1, "testbranch")
+	id, err := API.CreateBranchRelease(context.Background(), 1, "testbranch")
 	if err != nil {
 		t.Errorf("error not nil: %s\n", err)
 	}
@@ -144,6 +140,38 @@ func Test_CreateBranchRelease(t *testing.T) {
 	}
 }
 
+const validReleasesResponse = `{"releases":[{"id":12,"branch_name":"main","status":"success","created_at":"2024-03-01T10:00:00Z","sync_warnings":["missing description"]},{"id":11,"branch_name":"fix","status":"failed","created_at":"2024-02-28T09:30:00Z"}]}`
+
+func Test_GetBlockReleases(t *testing.T) {
+	mockClient := api.MockResponse(validReleasesResponse)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+
+	releases, err := API.GetBlockReleases(context.Background(), 1)
+	if err != nil {
+		t.Errorf("error not nil: %s\n", err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("expected 2 releases but got %d", len(releases))
+	}
+	if releases[0].ID != 12 || releases[0].BranchName != "main" || releases[0].Status != "success" || len(releases[0].SyncWarnings) != 1 {
+		t.Errorf("release was not parsed properly: %+v", releases[0])
+	}
+	if releases[1].CreatedAt.Year() != 2024 || releases[1].CreatedAt.Month() != 2 {
+		t.Errorf("created_at was not parsed properly: %s", releases[1].CreatedAt)
+	}
+
+	req := mockClient.Requests[1]
+	if req.Method != "GET" {
+		t.Errorf("Request made to Learn should be a GET, was %s", req.Method)
+	}
+	if req.URL.String() != "https://example.com/api/v1/blocks/1/releases" {
+		t.Errorf("Request made to Learn should be to url '%s' but was '%s'\n", "https://example.com/api/v1/blocks/1/releases", req.URL.String())
+	}
+	if req.Header.Get("Authorization") != "Bearer apiToken" {
+		t.Errorf("Authorization header should be 'Basic apiToken', was '%s'\n", req.Header.Get("Authorization"))
+	}
+}
+
 func testValidBlockSerialization(block Block, t *testing.T) {
 	if block.ID != 1 {
 		t.Errorf("block response should have id of 1, but got %d\n", block.ID)
@@ -0,0 +1,108 @@
+package learn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ErrMissingAPIToken is returned when no api_token has been set in the config
+var ErrMissingAPIToken = errors.New("Please set your API token with this command: learn set --api_token=your-token-from-https://learn-2.galvanize.com/api_token")
+
+// APIError is returned by every APIClient method when Learn responds with a status other than 200.
+// Use errors.As to inspect it, e.g. to ask for a new token when StatusCode is 401.
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Endpoint is the method and path of the request, e.g. GET /api/v1/blocks
+	Endpoint string
+	// Title is the main error message in the response body, if any
+	Title string
+	// Errors are any additional messages in the response body
+	Errors []string
+	// RequestID is the X-Request-Id of the response, useful when reporting a problem to Learn
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("Error: response status: %d", e.StatusCode)
+	if e.Endpoint != "" {
+		msg += fmt.Sprintf(" from %s", e.Endpoint)
+	}
+	if e.Title != "" {
+		msg += fmt.Sprintf("\n %s", e.Title)
+	}
+	for _, m := range e.Errors {
+		msg += fmt.Sprintf("\n %s", m)
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf("\n (request id: %s)", e.RequestID)
+	}
+	return msg
+}
+
+// Unauthorized reports if Learn rejected the API token
+func (e *APIError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+// Forbidden reports if the API token is valid but lacks permission for the request
+func (e *APIError) Forbidden() bool {
+	return e.StatusCode == http.StatusForbidden
+}
+
+// Temporary reports if the request may succeed when tried again later
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// newAPIError builds an APIError from a response which was not 200, consuming its body. Learn
+// reports errors as {"errors":{"status":400,"title":"..."}}, {"errors":"..."} or {"errors":["..."]}.
+func newAPIError(res *http.Response) *APIError {
+	e := &APIError{
+		StatusCode: res.StatusCode,
+		RequestID:  res.Header.Get("X-Request-Id"),
+	}
+	if res.Request != nil {
+		e.Endpoint = fmt.Sprintf("%s %s", res.Request.Method, res.Request.URL.Path)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil || len(body) == 0 {
+		return e
+	}
+
+	var raw struct {
+		Errors json.RawMessage `json:"errors"`
+		Title  string          `json:"title"`
+	}
+	if json.Unmarshal(body, &raw) != nil {
+		return e
+	}
+	e.Title = strings.TrimSpace(raw.Title)
+	if len(raw.Errors) == 0 || string(raw.Errors) == "null" {
+		return e
+	}
+
+	var single Error
+	var message string
+	var messages []string
+	switch {
+	case json.Unmarshal(raw.Errors, &message) == nil:
+		e.Title = message
+	case json.Unmarshal(raw.Errors, &messages) == nil:
+		for _, m := range messages {
+			if m = strings.TrimSpace(m); m != "" {
+				e.Errors = append(e.Errors, m)
+			}
+		}
+	case json.Unmarshal(raw.Errors, &single) == nil:
+		e.Title = single.Title
+	}
+	e.Title = strings.TrimSpace(e.Title)
+
+	return e
+}
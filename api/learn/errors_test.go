@@ -0,0 +1,90 @@
+package learn
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gSchool/glearn-cli/api"
+)
+
+func Test_newAPIError(t *testing.T) {
+	tests := []struct {
+		body   string
+		title  string
+		errors []string
+	}{
+		{`{"errors":{"status":400,"title":"Url not found"}}`, "Url not found", nil},
+		{`{"errors":"Build failed"}`, "Build failed", nil},
+		{`{"errors":["Repo is empty"," ","Title missing"]}`, "", []string{"Repo is empty", "Title missing"}},
+		{`{"title":"Forbidden"}`, "Forbidden", nil},
+		{`<html>Bad Gateway</html>`, "", nil},
+		{``, "", nil},
+	}
+
+	for _, tt := range tests {
+		req := &http.Request{Method: "POST", URL: &url.URL{Scheme: "https", Host: "example.com", Path: "/api/v1/blocks"}}
+		res := &http.Response{
+			StatusCode: 400,
+			Header:     http.Header{"X-Request-Id": {"abc-123"}},
+			Body:       api.MockBody([]byte(tt.body)),
+			Request:    req,
+		}
+
+		e := newAPIError(res)
+		if e.StatusCode != 400 || e.Endpoint != "POST /api/v1/blocks" || e.RequestID != "abc-123" {
+			t.Errorf("newAPIError should describe the response, got %+v", e)
+		}
+		if e.Title != tt.title {
+			t.Errorf("newAPIError title for '%s' should be '%s', got '%s'", tt.body, tt.title, e.Title)
+		}
+		if len(e.Errors) != len(tt.errors) {
+			t.Errorf("newAPIError errors for '%s' should be %v, got %v", tt.body, tt.errors, e.Errors)
+			continue
+		}
+		for i := range tt.errors {
+			if e.Errors[i] != tt.errors[i] {
+				t.Errorf("newAPIError errors for '%s' should be %v, got %v", tt.body, tt.errors, e.Errors)
+			}
+		}
+	}
+}
+
+func Test_APIError_ReturnedByMethods(t *testing.T) {
+	mockClient := api.MockResponses(credentialsResponse, `{"errors":{"status":403,"title":"Not a member of gSchool"}}`)
+	mockClient.StatusCodes = []int{200, 403}
+	API, err := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+	if err != nil {
+		t.Errorf("NewAPI error: %s", err)
+		return
+	}
+
+	_, err = API.CreateBlockByRepoName(context.Background(), RepoPieces{Origin: "github.com", Org: "gSchool", RepoName: "blocks-test"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Errorf("CreateBlockByRepoName should return an APIError, got %v", err)
+		return
+	}
+	if !apiErr.Forbidden() || apiErr.Unauthorized() || apiErr.Temporary() {
+		t.Errorf("APIError should only be Forbidden, got %+v", apiErr)
+	}
+	if apiErr.Title != "Not a member of gSchool" || apiErr.Endpoint != "POST /api/v1/blocks" {
+		t.Errorf("APIError should have the decoded title and endpoint, got %+v", apiErr)
+	}
+}
+
+func Test_NewAPI_WrapsCredentialsErrors(t *testing.T) {
+	mockClient := api.MockResponse(`{"errors":"Invalid token"}`)
+	mockClient.StatusCode = 401
+
+	_, err := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.Unauthorized() {
+		t.Errorf("NewAPI should wrap the APIError from retrieving credentials, got %v", err)
+	}
+	if len(mockClient.Requests) != 1 {
+		t.Errorf("a 401 should not be retried, made %d requests", len(mockClient.Requests))
+	}
+}
@@ -2,14 +2,13 @@ package learn
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gSchool/glearn-cli/api"
-	"github.com/spf13/viper"
 )
 
 // API is the exported APIClient, it is set during Init
@@ -23,7 +22,16 @@ var LearnUserEmail string
 type APIClient struct {
 	client      api.Client
 	baseURL     string
+	apiToken    string
 	Credentials *Credentials
+	// Retry configures how failed requests are retried
+	Retry RetryPolicy
+	// RequestTimeout bounds each attempt of a request, there is no bound when zero
+	RequestTimeout time.Duration
+	// PollInterval is the wait between checks of a release build
+	PollInterval time.Duration
+	// PollTimeout is the total time to wait for a release to build
+	PollTimeout time.Duration
 }
 
 // Credentials represents the shape of data that the initial call to Learn
@@ -68,19 +76,23 @@ type CLIBenchmark struct {
 	CmdName               string `json:"command_name,omitempty"`
 }
 
-// NewAPI is a constructor for the ApiClient
-func NewAPI(baseURL string, client api.Client, getPresignedPostUrl bool) (*APIClient, error) {
+// NewAPI is a constructor for the ApiClient, using the package defaults for retries, timeouts and polling.
+// The apiToken is exchanged for the CLI's credentials on Learn at baseURL.
+func NewAPI(ctx context.Context, baseURL, apiToken string, client api.Client, getPresignedPostUrl bool) (*APIClient, error) {
 	apiClient := &APIClient{
-		client:  client,
-		baseURL: baseURL,
+		client:         client,
+		baseURL:        baseURL,
+		apiToken:       apiToken,
+		Retry:          DefaultRetryPolicy,
+		RequestTimeout: DefaultRequestTimeout,
+		PollInterval:   DefaultPollInterval,
+		PollTimeout:    DefaultPollTimeout,
 	}
 
 	// Retrieve the application credentials for the CLI using a user's API token
-	creds, err := apiClient.RetrieveCredentials(getPresignedPostUrl)
+	creds, err := apiClient.RetrieveCredentials(ctx, getPresignedPostUrl)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"Could not retrieve credentials from Learn. Please reset your API token with this command: learn set --api_token=your-token-from-%s/api_token\n\n", baseURL,
-		)
+		return nil, fmt.Errorf("Could not retrieve credentials from Learn: %w", err)
 	}
 
 	apiClient.Credentials = creds
@@ -95,11 +107,11 @@ func (api *APIClient) BaseURL() string {
 
 // RetrieveCredentials uses a user's api_token to request AWS credentials
 // from Learn. It returns a populated *S3Credentials struct or an error
-func (api *APIClient) RetrieveCredentials(getPresignedPostUrl bool) (*Credentials, error) {
+func (api *APIClient) RetrieveCredentials(ctx context.Context, getPresignedPostUrl bool) (*Credentials, error) {
 	// Early return if user's api_token is not set
-	apiToken, ok := viper.Get("api_token").(string)
-	if !ok {
-		return nil, errors.New("Please set your API token with this command: learn set --api_token=your-token-from-https://learn-2.galvanize.com/api_token")
+	apiToken := api.apiToken
+	if apiToken == "" {
+		return nil, ErrMissingAPIToken
 	}
 
 	// add presignedParam to request one in the response
@@ -116,14 +128,14 @@ func (api *APIClient) RetrieveCredentials(getPresignedPostUrl bool) (*Credential
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiToken))
 
-	res, err := api.client.Do(req)
+	res, err := api.do(ctx, req, true)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Error: response status: %d", res.StatusCode)
+		return nil, newAPIError(res)
 	}
 
 	var c CredentialsResponse
@@ -147,7 +159,7 @@ func (api *APIClient) RetrieveCredentials(getPresignedPostUrl bool) (*Credential
 
 // SendMetadataToLearn takes a *CLIBenchmarkPayload struct payload to send to Learn
 // for monitoring how long everything is taking
-func (api *APIClient) SendMetadataToLearn(timingPayload *CLIBenchmarkPayload) error {
+func (api *APIClient) SendMetadataToLearn(ctx context.Context, timingPayload *CLIBenchmarkPayload) error {
 	payloadBytes, err := json.Marshal(timingPayload)
 	if err != nil {
 		return err
@@ -169,38 +181,55 @@ func (api *APIClient) SendMetadataToLearn(timingPayload *CLIBenchmarkPayload) er
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Credentials.token))
 
-	res, err := api.client.Do(req)
+	res, err := api.do(ctx, req, false)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("Error: response status: %d", res.StatusCode)
+		return newAPIError(res)
 	}
 
 	return nil
 }
 
-// NotifySlack is used throughout the CLI for production error handling
-func (api *APIClient) NotifySlack(err error) {
+// NotifySlack posts text to Learn's channel for CLI errors. What is sent, and whether anything is, is
+// decided by the caller.
+func (api *APIClient) NotifySlack(ctx context.Context, text string) error {
 	// Do not notify slack during development
 	if api.Credentials.DevNotifyURL == "development" {
-		return
+		return nil
 	}
 
 	msg := struct {
 		Text string `json:"text"`
 	}{
-		Text: fmt.Sprintf("UserId: %d\nUserEmail: %s\n%s", LearnUserId, LearnUserEmail, err),
+		Text: text,
+	}
+
+	bytePostData, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", api.Credentials.DevNotifyURL, bytes.NewReader(bytePostData))
+	if err != nil {
+		return err
 	}
+	req.Header.Add("Content-Type", "application/json; charset=utf-8")
 
-	bytePostData, _ := json.Marshal(msg)
+	res, err := api.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 
-	req, err := http.NewRequest("POST", api.Credentials.DevNotifyURL, bytes.NewReader(bytePostData))
-	if err == nil {
-		req.Header.Add("Content-Type", "application/json; charset=utf-8")
-		client := &http.Client{Timeout: time.Second * 30}
-		client.Do(req)
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Error: response status: %d from Slack", res.StatusCode)
 	}
+	return nil
 }
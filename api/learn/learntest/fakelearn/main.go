@@ -0,0 +1,59 @@
+// Command fakelearn serves the learntest fake Learn API so the CLI can be run end-to-end offline:
+//
+//	go run ./api/learn/learntest/fakelearn -addr 127.0.0.1:8080
+//	LEARN_BASE_URL=http://127.0.0.1:8080 learn preview ./some-unit
+//
+// The CLI must be configured with the fake's token, see the -token flag.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/gSchool/glearn-cli/api/learn/learntest"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "The address to listen on")
+	token := flag.String("token", learntest.Token, "The API token to accept, any token is accepted when empty")
+	buildPolls := flag.Int("build-polls", 1, "The number of polls a release reports processing before it is built")
+	buildErrors := flag.String("build-errors", "", "Fail every release with these errors")
+	warnings := flag.String("sync-warnings", "", "Comma separated warnings to report on every release")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Could not listen on %s: %v", *addr, err)
+	}
+
+	s := learntest.NewUnstartedServer()
+	s.Listener.Close()
+	s.Listener = listener
+	s.Token = *token
+	s.BuildPolls = *buildPolls
+	s.BuildErrors = *buildErrors
+	if *warnings != "" {
+		s.SyncWarnings = strings.Split(*warnings, ",")
+	}
+	s.Start()
+	defer s.Close()
+
+	fmt.Printf("Fake Learn API listening on %s\n\n", s.URL)
+	fmt.Printf("export LEARN_BASE_URL=%s\n", s.URL)
+	if *token != "" {
+		fmt.Printf("learn set --api_token=%s\n", *token)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+
+	for _, r := range s.Requests() {
+		fmt.Println(r)
+	}
+}
@@ -0,0 +1,480 @@
+// Package learntest provides a fake Learn API for tests and local development. The fake keeps
+// blocks, releases and uploads in memory, builds releases over a configurable number of polls,
+// and can be told to fail any route with a given status code.
+package learntest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+)
+
+// Token is the API token the fake accepts unless Server.Token is changed
+const Token = "learntest-token"
+
+// Routes of the fake, used to inject failures with FailNext
+const (
+	RouteCredentials   = "cli_access"
+	RouteBlocks        = "blocks"
+	RouteBlockReleases = "block_releases"
+	RoutePreviewBuild  = "preview_build"
+	RouteContentFiles  = "content_files"
+	RoutePolling       = "release_polling"
+	RouteMetadata      = "learn_cli_metadata"
+	RouteUpload        = "upload"
+)
+
+// release is a release of a block, or a preview build, held by the fake
+type release struct {
+	learn.Release
+	blockID    int
+	polls      int
+	errors     string
+	previewURL string
+}
+
+// Server is a fake Learn API served over HTTP. Configure the exported fields before making requests.
+type Server struct {
+	*httptest.Server
+
+	// Token is the API token requests must use, any token is accepted when empty
+	Token string
+	// UserID and UserEmail are returned with credentials
+	UserID    int
+	UserEmail string
+	// BuildPolls is the number of polls a release reports processing before it finishes building
+	BuildPolls int
+	// BuildErrors makes releases fail with these errors once built, also setting the block's sync errors
+	BuildErrors string
+	// SyncWarnings are reported on every release once built
+	SyncWarnings []string
+
+	mu       sync.Mutex
+	nextID   int
+	blocks   []learn.Block
+	releases []*release
+	uploads  map[string][]byte
+	metadata []learn.CLIBenchmarkPayload
+	failures map[string][]int
+	requests []string
+}
+
+// NewServer starts a fake Learn API on a local port, close it with Close
+func NewServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// NewUnstartedServer returns a fake Learn API which has not started, so it can be served on another listener
+func NewUnstartedServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+func newServer() *Server {
+	return &Server{
+		Token:      Token,
+		UserID:     1,
+		UserEmail:  "learntest@example.com",
+		BuildPolls: 1,
+		nextID:     1,
+		uploads:    map[string][]byte{},
+		failures:   map[string][]int{},
+	}
+}
+
+// FailNext makes the next requests to route respond with each of the status codes in turn
+func (s *Server) FailNext(route string, statusCodes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[route] = append(s.failures[route], statusCodes...)
+}
+
+// AddBlock stores a block as if it had been published, giving it an id when it has none
+func (s *Server) AddBlock(b learn.Block) learn.Block {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addBlock(b)
+}
+
+func (s *Server) addBlock(b learn.Block) learn.Block {
+	if b.ID == 0 {
+		b.ID = s.id()
+	}
+	if b.Title == "" {
+		b.Title = b.RepoName
+	}
+	s.blocks = append(s.blocks, b)
+	return b
+}
+
+// Blocks returns every block held by the fake
+func (s *Server) Blocks() []learn.Block {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]learn.Block{}, s.blocks...)
+}
+
+// Releases returns the releases of a block, newest first
+func (s *Server) Releases(blockID int) []learn.Release {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blockReleases(blockID)
+}
+
+// Upload returns the content uploaded to the presigned url for an s3 key
+func (s *Server) Upload(s3Key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.uploads[s3Key]
+	return b, ok
+}
+
+// Metadata returns the benchmarks sent to learn_cli_metadata
+func (s *Server) Metadata() []learn.CLIBenchmarkPayload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]learn.CLIBenchmarkPayload{}, s.metadata...)
+}
+
+// Requests returns the method and path of every request received, e.g. "GET /api/v1/blocks"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) id() int {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+// ServeHTTP routes requests to the fake endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the body is read and the response recorded outside the lock, so slow clients do not hold up others
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("learntest: could not read body: %v", err))
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	rec := httptest.NewRecorder()
+	s.serve(rec, r)
+
+	for key, values := range rec.Header() {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+}
+
+// serve handles a request whose body has already been read, holding the lock while state is updated
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	route, handler := s.route(r.Method, parts)
+	if handler == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("learntest: no route for %s %s", r.Method, r.URL.Path))
+		return
+	}
+
+	if queued := s.failures[route]; len(queued) > 0 {
+		s.failures[route] = queued[1:]
+		writeError(w, queued[0], fmt.Sprintf("learntest: injected failure for %s", route))
+		return
+	}
+
+	if route != RouteUpload {
+		if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+			writeError(w, http.StatusUnauthorized, "Invalid API token")
+			return
+		}
+		if r.Header.Get("Source") != "gLearn_cli" {
+			writeError(w, http.StatusBadRequest, "learntest: missing Source header")
+			return
+		}
+	}
+
+	handler(w, r, parts)
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, parts []string)
+
+// route matches the method and path parts to a route name and its handler
+func (s *Server) route(method string, parts []string) (string, handlerFunc) {
+	if len(parts) == 2 && parts[0] == "upload" && method == "PUT" {
+		return RouteUpload, s.upload
+	}
+	if len(parts) < 3 || parts[0] != "api" || parts[1] != "v1" {
+		return "", nil
+	}
+	parts = parts[2:]
+
+	switch {
+	case method == "GET" && len(parts) == 2 && parts[0] == "users" && parts[1] == "cli_access":
+		return RouteCredentials, s.credentials
+	case method == "POST" && len(parts) == 2 && parts[0] == "users" && parts[1] == "learn_cli_metadata":
+		return RouteMetadata, s.cliMetadata
+	case method == "GET" && len(parts) == 1 && parts[0] == "blocks":
+		return RouteBlocks, s.getBlocks
+	case method == "POST" && len(parts) == 1 && parts[0] == "blocks":
+		return RouteBlocks, s.createBlock
+	case method == "GET" && len(parts) == 3 && parts[0] == "blocks" && parts[2] == "releases":
+		return RouteBlockReleases, s.listReleases
+	case method == "POST" && len(parts) == 3 && parts[0] == "blocks" && parts[2] == "releases":
+		return RouteBlockReleases, s.createRelease
+	case method == "POST" && len(parts) == 1 && parts[0] == "releases":
+		return RoutePreviewBuild, s.previewBuild
+	case method == "POST" && len(parts) == 1 && parts[0] == "content_files":
+		return RouteContentFiles, s.contentFile
+	case method == "GET" && len(parts) == 3 && parts[0] == "releases" && parts[2] == "release_polling":
+		return RoutePolling, s.poll
+	}
+	return "", nil
+}
+
+func (s *Server) credentials(w http.ResponseWriter, r *http.Request, parts []string) {
+	c := learn.CredentialsResponse{
+		UserId:       s.UserID,
+		Email:        s.UserEmail,
+		S3Key:        fmt.Sprintf("learntest/%d.zip", s.id()),
+		DevNotifyUrl: "development",
+	}
+	if r.URL.Query().Get("presigned_url") == "true" {
+		c.PresignedUrl = fmt.Sprintf("%s/upload/%s", s.URL, strings.TrimPrefix(c.S3Key, "learntest/"))
+	}
+	writeJSON(w, c)
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request, parts []string) {
+	// like S3, presigned uploads need their length up front and cannot be chunked
+	if r.ContentLength < 0 {
+		writeError(w, http.StatusLengthRequired, "learntest: uploads need a Content-Length")
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.uploads["learntest/"+parts[1]] = body
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) cliMetadata(w http.ResponseWriter, r *http.Request, parts []string) {
+	var payload learn.CLIBenchmarkPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.CLIBenchmark == nil {
+		writeError(w, http.StatusUnprocessableEntity, "learntest: expected a cli_benchmark payload")
+		return
+	}
+	s.metadata = append(s.metadata, payload)
+	writeJSON(w, map[string]string{})
+}
+
+func (s *Server) getBlocks(w http.ResponseWriter, r *http.Request, parts []string) {
+	q := r.URL.Query()
+	if q.Get("repo_name") == "" || q.Get("org") == "" || q.Get("origin") == "" {
+		writeError(w, http.StatusBadRequest, "repo_name, org and origin are required")
+		return
+	}
+
+	blocks := []learn.Block{}
+	if b := s.findBlock(q.Get("origin"), q.Get("org"), q.Get("repo_name")); b != nil {
+		blocks = append(blocks, *b)
+	}
+	writeJSON(w, map[string][]learn.Block{"blocks": blocks})
+}
+
+func (s *Server) createBlock(w http.ResponseWriter, r *http.Request, parts []string) {
+	var post learn.BlockPost
+	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	b := post.Block
+	if b.Origin == "" || b.Org == "" || b.RepoName == "" {
+		writeError(w, http.StatusUnprocessableEntity, "origin, org and repo_name are required")
+		return
+	}
+	if s.findBlock(b.Origin, b.Org, b.RepoName) != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Repo name has already been taken")
+		return
+	}
+
+	b = s.addBlock(learn.Block{Origin: b.Origin, Org: b.Org, RepoName: b.RepoName, Title: b.Title})
+	writeJSON(w, map[string][]learn.Block{"blocks": {b}})
+}
+
+func (s *Server) listReleases(w http.ResponseWriter, r *http.Request, parts []string) {
+	blockID, ok := s.blockID(w, parts[3])
+	if !ok {
+		return
+	}
+	writeJSON(w, map[string][]learn.Release{"releases": s.blockReleases(blockID)})
+}
+
+func (s *Server) createRelease(w http.ResponseWriter, r *http.Request, parts []string) {
+	blockID, ok := s.blockID(w, parts[3])
+	if !ok {
+		return
+	}
+	branch := r.URL.Query().Get("branch_name")
+	if branch == "" {
+		writeError(w, http.StatusBadRequest, "branch_name is required")
+		return
+	}
+
+	rel := s.addRelease(blockID, branch)
+	writeJSON(w, learn.ReleaseResponse{ReleaseID: rel.ID})
+}
+
+func (s *Server) previewBuild(w http.ResponseWriter, r *http.Request, parts []string) {
+	if !s.uploaded(w, r) {
+		return
+	}
+	rel := s.addRelease(0, "")
+	writeJSON(w, learn.PreviewResponse{ReleaseID: rel.ID, Status: rel.Status})
+}
+
+// contentFile builds a single file preview, which Learn responds to with the preview url right away
+func (s *Server) contentFile(w http.ResponseWriter, r *http.Request, parts []string) {
+	if !s.uploaded(w, r) {
+		return
+	}
+	rel := s.addRelease(0, "")
+	rel.polls = s.BuildPolls + 1
+	writeJSON(w, s.build(rel))
+}
+
+func (s *Server) poll(w http.ResponseWriter, r *http.Request, parts []string) {
+	id, err := strconv.Atoi(parts[3])
+	var rel *release
+	for _, candidate := range s.releases {
+		if err == nil && candidate.ID == id {
+			rel = candidate
+		}
+	}
+	if rel == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Release %s not found", parts[3]))
+		return
+	}
+
+	rel.polls++
+	writeJSON(w, s.build(rel))
+}
+
+// build reports the state of a release, finishing the build once it has been polled BuildPolls times
+func (s *Server) build(rel *release) learn.PreviewResponse {
+	if rel.Status == "processing" && rel.polls > s.BuildPolls {
+		rel.Status = "success"
+		rel.SyncWarnings = s.SyncWarnings
+		if s.BuildErrors != "" {
+			rel.Status = "failed"
+			rel.errors = s.BuildErrors
+			for i := range s.blocks {
+				if s.blocks[i].ID == rel.blockID {
+					s.blocks[i].SyncErrors = []string{s.BuildErrors}
+				}
+			}
+		}
+	}
+
+	return learn.PreviewResponse{
+		ReleaseID:    rel.ID,
+		PreviewURL:   rel.previewURL,
+		Errors:       rel.errors,
+		Status:       rel.Status,
+		SyncWarnings: rel.SyncWarnings,
+	}
+}
+
+// uploaded checks the s3_key of a preview build was uploaded to its presigned url
+func (s *Server) uploaded(w http.ResponseWriter, r *http.Request) bool {
+	var payload map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if _, ok := s.uploads[payload["s3_key"]]; !ok {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Nothing was uploaded for s3_key '%s'", payload["s3_key"]))
+		return false
+	}
+	return true
+}
+
+func (s *Server) addRelease(blockID int, branch string) *release {
+	rel := &release{
+		Release: learn.Release{ID: s.id(), BranchName: branch, Status: "processing", CreatedAt: time.Now()},
+		blockID: blockID,
+	}
+	rel.previewURL = fmt.Sprintf("%s/blocks/%d?release_id=%d", s.URL, blockID, rel.ID)
+	if blockID == 0 {
+		rel.previewURL = fmt.Sprintf("%s/previews/%d", s.URL, rel.ID)
+	}
+	s.releases = append(s.releases, rel)
+	return rel
+}
+
+func (s *Server) blockReleases(blockID int) []learn.Release {
+	releases := []learn.Release{}
+	for _, rel := range s.releases {
+		if rel.blockID == blockID {
+			releases = append(releases, rel.Release)
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool { return releases[i].ID > releases[j].ID })
+	return releases
+}
+
+func (s *Server) findBlock(origin, org, repoName string) *learn.Block {
+	for i, b := range s.blocks {
+		if b.Origin == origin && b.Org == org && b.RepoName == repoName {
+			return &s.blocks[i]
+		}
+	}
+	return nil
+}
+
+// blockID parses the id of an existing block from a path part, responding with an error otherwise
+func (s *Server) blockID(w http.ResponseWriter, part string) (int, bool) {
+	id, err := strconv.Atoi(part)
+	if err == nil {
+		for _, b := range s.blocks {
+			if b.ID == id {
+				return id, true
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Block %s not found", part))
+	return 0, false
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds in the shape Learn uses for errors
+func writeError(w http.ResponseWriter, status int, title string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(learn.ErrorResponse{Errors: learn.Error{Status: status, Title: title}})
+}
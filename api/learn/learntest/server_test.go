@@ -0,0 +1,201 @@
+package learntest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+)
+
+// newAPI creates a Learn API client for the fake which polls without waiting
+func newAPI(t *testing.T, s *Server, getPresignedPostUrl bool) *learn.APIClient {
+	api, err := learn.NewAPI(context.Background(), s.URL, Token, s.Client(), getPresignedPostUrl)
+	if err != nil {
+		t.Fatalf("NewAPI error: %s", err)
+	}
+	api.PollInterval = time.Millisecond
+	api.Retry.InitialBackoff = time.Millisecond
+	return api
+}
+
+func Test_Publish(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.BuildPolls = 2
+	s.SyncWarnings = []string{"Unit has no lessons"}
+	api := newAPI(t, s, false)
+	ctx := context.Background()
+
+	repo := learn.RepoPieces{Origin: "github.com", Org: "gSchool", RepoName: "blocks-test"}
+	block, err := api.GetBlockByRepoName(ctx, repo)
+	if err != nil || block.Exists() {
+		t.Errorf("GetBlockByRepoName should find no block before publishing, got %+v and %v", block, err)
+	}
+
+	block, err = api.CreateBlockByRepoName(ctx, repo)
+	if err != nil || !block.Exists() || block.RepoName != "blocks-test" {
+		t.Errorf("CreateBlockByRepoName should create the block, got %+v and %v", block, err)
+	}
+	if _, err = api.CreateBlockByRepoName(ctx, repo); err == nil {
+		t.Errorf("CreateBlockByRepoName should fail for an existing block")
+	}
+
+	releaseID, err := api.CreateBranchRelease(ctx, block.ID, "master")
+	if err != nil {
+		t.Errorf("CreateBranchRelease error: %s", err)
+	}
+
+	p, err := api.GetReleaseStatus(ctx, releaseID, false, "")
+	if err != nil || !p.IsBuilding() {
+		t.Errorf("the release should be building before BuildPolls polls, got %+v and %v", p, err)
+	}
+
+	p, err = api.PollForBuildResponse(ctx, releaseID, false, "")
+	if err != nil || p.Status != "success" || len(p.SyncWarnings) != 1 {
+		t.Errorf("PollForBuildResponse should report the built release with warnings, got %+v and %v", p, err)
+	}
+
+	releases, err := api.GetBlockReleases(ctx, block.ID)
+	if err != nil || len(releases) != 1 || releases[0].ID != releaseID || releases[0].BranchName != "master" {
+		t.Errorf("GetBlockReleases should list the release, got %+v and %v", releases, err)
+	}
+}
+
+func Test_PublishBuildErrors(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.BuildErrors = "config.yaml is missing"
+	api := newAPI(t, s, false)
+	ctx := context.Background()
+
+	block := s.AddBlock(learn.Block{Origin: "github.com", Org: "gSchool", RepoName: "blocks-test"})
+	releaseID, err := api.CreateBranchRelease(ctx, block.ID, "master")
+	if err != nil {
+		t.Errorf("CreateBranchRelease error: %s", err)
+	}
+
+	p, err := api.PollForBuildResponse(ctx, releaseID, false, "")
+	if err != nil || p.Status != "failed" || p.Errors != s.BuildErrors {
+		t.Errorf("PollForBuildResponse should report the failed release, got %+v and %v", p, err)
+	}
+
+	block, err = api.GetBlockByRepoName(ctx, learn.RepoPieces{Origin: "github.com", Org: "gSchool", RepoName: "blocks-test"})
+	if err != nil || len(block.SyncErrors) != 1 {
+		t.Errorf("the block should have sync errors after a failed release, got %+v and %v", block, err)
+	}
+}
+
+func Test_Preview(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	api := newAPI(t, s, true)
+	ctx := context.Background()
+
+	_, err := api.BuildReleaseFromS3(ctx, api.Credentials.S3Key, true)
+	if err == nil {
+		t.Errorf("BuildReleaseFromS3 should fail before anything is uploaded")
+	}
+
+	req, _ := http.NewRequest("PUT", api.Credentials.PresignedUrl, io.MultiReader(bytes.NewBufferString("zip")))
+	res, err := s.Client().Do(req)
+	if err != nil || res.StatusCode != http.StatusLengthRequired {
+		t.Errorf("an upload without a Content-Length should be refused like S3 does, got %v and %v", res, err)
+	}
+
+	req, _ = http.NewRequest("PUT", api.Credentials.PresignedUrl, bytes.NewBufferString("zip"))
+	res, err = s.Client().Do(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Errorf("uploading to the presigned url should succeed, got %v and %v", res, err)
+	}
+	if b, ok := s.Upload(api.Credentials.S3Key); !ok || string(b) != "zip" {
+		t.Errorf("the upload should be stored under the s3 key '%s'", api.Credentials.S3Key)
+	}
+
+	p, err := api.BuildReleaseFromS3(ctx, api.Credentials.S3Key, false)
+	if err != nil || p.Status != "success" || p.PreviewURL == "" {
+		t.Errorf("a single file preview should build right away, got %+v and %v", p, err)
+	}
+
+	p, err = api.BuildReleaseFromS3(ctx, api.Credentials.S3Key, true)
+	if err != nil || !p.IsBuilding() {
+		t.Errorf("a directory preview should start building, got %+v and %v", p, err)
+	}
+	p, err = api.PollForBuildResponse(ctx, p.ReleaseID, true, "")
+	if err != nil || p.Status != "success" || p.PreviewURL == "" {
+		t.Errorf("a directory preview should build after polling, got %+v and %v", p, err)
+	}
+
+	err = api.SendMetadataToLearn(ctx, &learn.CLIBenchmarkPayload{CLIBenchmark: &learn.CLIBenchmark{CmdName: "preview"}})
+	if err != nil || len(s.Metadata()) != 1 {
+		t.Errorf("SendMetadataToLearn should store the benchmark, got %v", err)
+	}
+}
+
+func Test_FailNext(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.FailNext(RouteBlocks, 503, 404)
+	api := newAPI(t, s, false)
+
+	repo := learn.RepoPieces{Origin: "github.com", Org: "gSchool", RepoName: "blocks-test"}
+	_, err := api.GetBlockByRepoName(context.Background(), repo)
+	var apiErr *learn.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 404 {
+		t.Errorf("GetBlockByRepoName should retry the 503 and fail on the 404, got %v", err)
+	}
+
+	_, err = api.GetBlockByRepoName(context.Background(), repo)
+	if err != nil {
+		t.Errorf("GetBlockByRepoName should succeed once the failures are used, got %v", err)
+	}
+	if len(s.Requests()) != 4 {
+		t.Errorf("the fake should receive credentials and three block requests, got %v", s.Requests())
+	}
+}
+
+func Test_RejectsToken(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Token = "another-token"
+
+	_, err := learn.NewAPI(context.Background(), s.URL, Token, s.Client(), false)
+	var apiErr *learn.APIError
+	if !errors.As(err, &apiErr) || !apiErr.Unauthorized() {
+		t.Errorf("NewAPI should fail with a 401 for the wrong token, got %v", err)
+	}
+}
+
+func Test_SlowBodyDoesNotBlock(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	api := newAPI(t, s, false)
+
+	body, w := io.Pipe()
+	defer w.Close()
+	req, _ := http.NewRequest("PUT", s.URL+"/upload/slow", body)
+	req.ContentLength = 100
+	go s.Client().Do(req)
+	w.Write([]byte("first part"))
+	// give the server time to start reading the body before the next request
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		_, err := api.GetBlockByRepoName(context.Background(), learn.RepoPieces{Origin: "github.com", Org: "gSchool", RepoName: "blocks-test"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("GetBlockByRepoName error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("a request should be served while another is still sending its body")
+	}
+}
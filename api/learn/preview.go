@@ -2,12 +2,11 @@ package learn
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"time"
 )
 
 // PreviewResponse is a simple struct defining the shape of data we care about
@@ -20,8 +19,9 @@ type PreviewResponse struct {
 	SyncWarnings []string `json:"sync_warnings"`
 }
 
-// PollForBuildResponse attempts to check if a release has finished building every 2 seconds.
-func (api *APIClient) PollForBuildResponse(releaseID int, isDir bool, fileName string, attempts *uint8) (*PreviewResponse, error) {
+// GetReleaseStatus makes a single request for the build state of a release. The context of a
+// directory preview or publish is DIRECTORY, while single file previews give the file name.
+func (api *APIClient) GetReleaseStatus(ctx context.Context, releaseID int, isDir bool, fileName string) (*PreviewResponse, error) {
 	context := fileName
 	if isDir {
 		context = "DIRECTORY"
@@ -38,7 +38,7 @@ func (api *APIClient) PollForBuildResponse(releaseID int, isDir bool, fileName s
 	req.Header.Set("Source", "gLearn_cli")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Credentials.token))
 
-	res, err := api.client.Do(req)
+	res, err := api.do(ctx, req, true)
 	if err != nil {
 		return nil, err
 	}
@@ -47,8 +47,9 @@ func (api *APIClient) PollForBuildResponse(releaseID int, isDir bool, fileName s
 	var p PreviewResponse
 
 	if res.StatusCode != http.StatusOK {
-		json.NewDecoder(res.Body).Decode(&p)
-		return &p, fmt.Errorf("Error: response status: %d, response body: %s", res.StatusCode, p.Errors)
+		apiErr := newAPIError(res)
+		p.Errors = apiErr.Title
+		return &p, apiErr
 	}
 
 	err = json.NewDecoder(res.Body).Decode(&p)
@@ -56,26 +57,34 @@ func (api *APIClient) PollForBuildResponse(releaseID int, isDir bool, fileName s
 		return nil, err
 	}
 
-	if p.Status == "processing" || p.Status == "pending" {
-		*attempts--
-
-		if *attempts <= uint8(0) {
-			return nil, errors.New(
-				"Sorry, we are having trouble requesting your build from Learn. Please try again",
-			)
-		}
+	return &p, nil
+}
 
-		time.Sleep(2 * time.Second)
+// IsBuilding reports if Learn has not finished building the release
+func (p *PreviewResponse) IsBuilding() bool {
+	return p.Status == "processing" || p.Status == "pending"
+}
 
-		return api.PollForBuildResponse(releaseID, isDir, fileName, attempts)
-	}
+// PollForBuildResponse checks if a release has finished building every PollInterval, giving up
+// with ErrPollTimeout once PollTimeout has passed. When polling stops early the last status
+// received, if any, is returned along with the error.
+func (api *APIClient) PollForBuildResponse(ctx context.Context, releaseID int, isDir bool, fileName string) (*PreviewResponse, error) {
+	var last *PreviewResponse
+	err := poll(ctx, api.PollInterval, api.PollTimeout, func(ctx context.Context) (bool, error) {
+		p, err := api.GetReleaseStatus(ctx, releaseID, isDir, fileName)
+		if err != nil {
+			return false, err
+		}
+		last = p
+		return !p.IsBuilding(), nil
+	})
 
-	return &p, nil
+	return last, err
 }
 
 // BuildReleaseFromS3 takes an s3 bucket key name as an argument is used to tell Learn there is new preview
 // content on s3 and where to find it so it can build/preview.
-func (api *APIClient) BuildReleaseFromS3(bucketKey string, isDirectory bool) (*PreviewResponse, error) {
+func (api *APIClient) BuildReleaseFromS3(ctx context.Context, bucketKey string, isDirectory bool) (*PreviewResponse, error) {
 	payload := map[string]string{
 		"s3_key": bucketKey,
 	}
@@ -106,17 +115,20 @@ func (api *APIClient) BuildReleaseFromS3(bucketKey string, isDirectory bool) (*P
 	req.Header.Set("Source", "gLearn_cli")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Credentials.token))
 
-	res, err := api.client.Do(req)
+	res, err := api.do(ctx, req, false)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	p := &PreviewResponse{}
-	json.NewDecoder(res.Body).Decode(p)
-
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Error: %s, response status: %d", p.Errors, res.StatusCode)
+		return nil, newAPIError(res)
+	}
+
+	p := &PreviewResponse{}
+	err = json.NewDecoder(res.Body).Decode(p)
+	if err != nil {
+		return nil, err
 	}
 
 	return p, nil
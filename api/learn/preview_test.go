@@ -1,11 +1,12 @@
 package learn
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	"github.com/gSchool/glearn-cli/api"
-	"github.com/spf13/viper"
 )
 
 const validPreviewResponse = `{"status":"success","release_id":1,"preview_url":"http://example.com"}`
@@ -13,12 +14,10 @@ const pendingPreviewResponse = `{"status":"pending","release_id":1,"preview_url"
 const credentialsResponse = `{"presigned_url":"https://aws-presigned-url.com", "dev_notify_url": "development","user_id":5,"user_email":"abc@example.com"}`
 
 func Test_PollForBuildResponse(t *testing.T) {
-	viper.Set("api_token", "apiToken")
 	mockClient := api.MockResponse(validPreviewResponse)
-	API, _ := NewAPI("https://example.com", mockClient, true)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, true)
 
-	attempts := uint8(1)
-	previewResponse, err := API.PollForBuildResponse(1, false, "foo.md", &attempts)
+	previewResponse, err := API.PollForBuildResponse(context.Background(), 1, false, "foo.md")
 	if err != nil {
 		t.Errorf("error not nil: %s\n", err)
 	}
@@ -55,15 +54,17 @@ func Test_PollForBuildResponse(t *testing.T) {
 }
 
 func Test_PollForBuildResponse_EndAttempts(t *testing.T) {
-	viper.Set("api_token", "apiToken")
 	mockClient := api.MockResponse(pendingPreviewResponse)
-	API, _ := NewAPI("https://example.com", mockClient, true)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, true)
 
-	attempts := uint8(1)
-	_, err := API.PollForBuildResponse(1, true, "", &attempts)
+	API.PollTimeout = 0
+	p, err := API.PollForBuildResponse(context.Background(), 1, true, "")
 	if err == nil {
 		t.Errorf("error should be present if attempts are exausted nil: %s\n", err)
 	}
+	if p == nil || !p.IsBuilding() {
+		t.Errorf("the last status received should be returned with the timeout, got %+v", p)
+	}
 	if fmt.Sprintf("%s", err) != "Sorry, we are having trouble requesting your build from Learn. Please try again" {
 		t.Errorf("error should specify that something is wrong requesting the build from learn")
 	}
@@ -96,12 +97,33 @@ func Test_PollForBuildResponse_EndAttempts(t *testing.T) {
 	}
 }
 
+func Test_GetReleaseStatus(t *testing.T) {
+	mockClient := api.MockResponse(pendingPreviewResponse)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, true)
+
+	previewResponse, err := API.GetReleaseStatus(context.Background(), 1, true, "")
+	if err != nil {
+		t.Errorf("error not nil: %s\n", err)
+	}
+	if !previewResponse.IsBuilding() {
+		t.Errorf("a pending release should be building")
+	}
+
+	// a single status request is made without polling
+	if len(mockClient.Requests) != 2 {
+		t.Errorf("fetching the status should make one request after credentials, made %d", len(mockClient.Requests))
+	}
+	urlTarget := "https://example.com/api/v1/releases/1/release_polling?context=DIRECTORY"
+	if mockClient.Requests[1].URL.String() != urlTarget {
+		t.Errorf("Request made to Learn should be to url '%s' but was '%s'\n", urlTarget, mockClient.Requests[1].URL.String())
+	}
+}
+
 func Test_BuildReleaseFromS3_Directory(t *testing.T) {
-	viper.Set("api_token", "apiToken")
 	mockClient := api.MockResponse(validPreviewResponse)
-	API, _ := NewAPI("https://example.com", mockClient, true)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, true)
 
-	previewResponse, err := API.BuildReleaseFromS3("buket", true)
+	previewResponse, err := API.BuildReleaseFromS3(context.Background(), "buket", true)
 	if err != nil {
 		t.Errorf("error not nil: %s\n", err)
 	}
@@ -139,11 +161,10 @@ func Test_BuildReleaseFromS3_Directory(t *testing.T) {
 }
 
 func Test_BuildReleaseFromS3_notDirectory(t *testing.T) {
-	viper.Set("api_token", "apiToken")
 	mockClient := api.MockResponses(credentialsResponse, validPreviewResponse)
-	API, _ := NewAPI("https://example.com", mockClient, true)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, true)
 
-	previewResponse, err := API.BuildReleaseFromS3("buket", false)
+	previewResponse, err := API.BuildReleaseFromS3(context.Background(), "buket", false)
 	if err != nil {
 		t.Errorf("error not nil: %s\n", err)
 	}
@@ -180,9 +201,8 @@ func Test_BuildReleaseFromS3_notDirectory(t *testing.T) {
 }
 
 func Test_RetrieveCredentials(t *testing.T) {
-	viper.Set("api_token", "apiToken")
 	mockClient := api.MockResponse(credentialsResponse)
-	API, _ := NewAPI("https://example.com", mockClient, true)
+	API, _ := NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, true)
 
 	if API.Credentials.DevNotifyURL != "development" {
 		t.Errorf("Error unmarshaling S3 Credentials, no dev_alert_url ")
@@ -214,3 +234,25 @@ func Test_RetrieveCredentials(t *testing.T) {
 		t.Errorf("Authorization header should be 'Basic apiToken', was '%s'\n", req.Header.Get("Authorization"))
 	}
 }
+
+func Test_NotifySlack(t *testing.T) {
+	mockClient := api.MockResponses("", "")
+	mockClient.StatusCodes = []int{200, 500}
+	API := retryTestAPI(mockClient)
+	API.Credentials.DevNotifyURL = "https://hooks.slack.com/services/T/B/X"
+
+	if err := API.NotifySlack(context.Background(), "UserId: 5\nsomething failed"); err != nil {
+		t.Errorf("NotifySlack should succeed, got %v", err)
+	}
+	if body, _ := ioutil.ReadAll(mockClient.Requests[0].Body); string(body) != `{"text":"UserId: 5\nsomething failed"}` {
+		t.Errorf("NotifySlack should post only the given text, got %s", body)
+	}
+	if err := API.NotifySlack(context.Background(), "something failed"); err == nil {
+		t.Errorf("NotifySlack should report a failed post")
+	}
+
+	API.Credentials.DevNotifyURL = "development"
+	if err := API.NotifySlack(context.Background(), "something failed"); err != nil || len(mockClient.Requests) != 2 {
+		t.Errorf("NotifySlack should not post during development")
+	}
+}
@@ -2,11 +2,13 @@ package learn
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // BlockPost represents the shape of the data needed to POST to learn for
@@ -43,6 +45,20 @@ type ReleaseResponse struct {
 	ReleaseID int `json:"release_id"`
 }
 
+// Release holds information about a block release yielded from the Learn API
+type Release struct {
+	ID           int       `json:"id"`
+	BranchName   string    `json:"branch_name"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	SyncWarnings []string  `json:"sync_warnings"`
+}
+
+// releasesResponse represents the shape of our Learn API release list responses
+type releasesResponse struct {
+	Releases []Release `json:"releases"`
+}
+
 // Error for bad responses in the api
 type Error struct {
 	Status int    `json:"status"`
@@ -62,7 +78,7 @@ func (b Block) Exists() bool {
 
 // GetBlockByRepoName takes a string repo name and requests a block from Learn. Returns
 // either the Block or an error
-func (api *APIClient) GetBlockByRepoName(repoPieces RepoPieces) (Block, error) {
+func (api *APIClient) GetBlockByRepoName(ctx context.Context, repoPieces RepoPieces) (Block, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/api/v1/blocks", api.baseURL))
 	if err != nil {
 		return Block{}, errors.New("unable to parse Learn remote")
@@ -82,14 +98,14 @@ func (api *APIClient) GetBlockByRepoName(repoPieces RepoPieces) (Block, error) {
 	req.Header.Set("Source", "gLearn_cli")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Credentials.token))
 
-	res, err := api.client.Do(req)
+	res, err := api.do(ctx, req, true)
 	if err != nil {
 		return Block{}, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return Block{}, fmt.Errorf("Error: response status: %d", res.StatusCode)
+		return Block{}, newAPIError(res)
 	}
 
 	var blockResp blockResponse
@@ -105,7 +121,7 @@ func (api *APIClient) GetBlockByRepoName(repoPieces RepoPieces) (Block, error) {
 }
 
 // CreateBlockByRepoName takes a string repo name and makes a POST to the Learn API to create the block
-func (api *APIClient) CreateBlockByRepoName(repoPieces RepoPieces) (Block, error) {
+func (api *APIClient) CreateBlockByRepoName(ctx context.Context, repoPieces RepoPieces) (Block, error) {
 	payload := BlockPost{Block: Block{Origin: repoPieces.Origin, Org: repoPieces.Org, RepoName: repoPieces.RepoName, Title: repoPieces.RepoName}}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -122,19 +138,14 @@ func (api *APIClient) CreateBlockByRepoName(repoPieces RepoPieces) (Block, error
 	req.Header.Set("Source", "gLearn_cli")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Credentials.token))
 
-	res, err := api.client.Do(req)
+	res, err := api.do(ctx, req, false)
 	if err != nil {
 		return Block{}, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		var e ErrorResponse
-		err = json.NewDecoder(res.Body).Decode(&e)
-		if err != nil {
-			return Block{}, fmt.Errorf("response status: %d", res.StatusCode)
-		}
-		return Block{}, fmt.Errorf("response status: %d\n %s", res.StatusCode, e.Errors.Title)
+		return Block{}, newAPIError(res)
 	}
 
 	var blockResp blockResponse
@@ -152,7 +163,7 @@ func (api *APIClient) CreateBlockByRepoName(repoPieces RepoPieces) (Block, error
 }
 
 // CreateBranchRelease takes a block ID and branch name and creates a release from it by POSTing to the Learn API
-func (api *APIClient) CreateBranchRelease(blockID int, branch string) (int, error) {
+func (api *APIClient) CreateBranchRelease(ctx context.Context, blockID int, branch string) (int, error) {
 	values := url.Values{"branch_name": {branch}}
 	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/v1/blocks/%d/releases?%s", api.baseURL, blockID, values.Encode()), nil)
 	if err != nil {
@@ -163,14 +174,14 @@ func (api *APIClient) CreateBranchRelease(blockID int, branch string) (int, erro
 	req.Header.Set("Source", "gLearn_cli")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Credentials.token))
 
-	res, err := api.client.Do(req)
+	res, err := api.do(ctx, req, false)
 	if err != nil {
 		return 0, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("Error: response status: %d", res.StatusCode)
+		return 0, newAPIError(res)
 	}
 
 	var r ReleaseResponse
@@ -182,3 +193,33 @@ func (api *APIClient) CreateBranchRelease(blockID int, branch string) (int, erro
 
 	return r.ReleaseID, nil
 }
+
+// GetBlockReleases takes a block ID and lists the block's releases, newest first
+func (api *APIClient) GetBlockReleases(ctx context.Context, blockID int) ([]Release, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/blocks/%d/releases", api.baseURL, blockID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Source", "gLearn_cli")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Credentials.token))
+
+	res, err := api.do(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newAPIError(res)
+	}
+
+	var r releasesResponse
+	err = json.NewDecoder(res.Body).Decode(&r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Releases, nil
+}
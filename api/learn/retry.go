@@ -0,0 +1,198 @@
+package learn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how requests to Learn are retried. Idempotent requests are retried
+// after network errors and 5xx or 429 responses. Other requests are only retried when Learn
+// reports it did not process them, with a 429 or 503 response.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubling for each retry after it
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is copied to each APIClient created with NewAPI
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     8 * time.Second,
+}
+
+// DefaultRequestTimeout bounds each attempt of a request made with an APIClient created with NewAPI
+var DefaultRequestTimeout = 15 * time.Second
+
+// DefaultPollInterval and DefaultPollTimeout are copied to each APIClient created with NewAPI
+var (
+	DefaultPollInterval = 2 * time.Second
+	DefaultPollTimeout  = 2 * time.Minute
+)
+
+// ErrPollTimeout is returned when a release does not finish building before the poll timeout
+var ErrPollTimeout = errors.New("Sorry, we are having trouble requesting your build from Learn. Please try again")
+
+// backoff returns the wait before the given retry, where the first retry is 1
+func (r RetryPolicy) backoff(retry int) time.Duration {
+	wait := r.InitialBackoff
+	for i := 1; i < retry && wait < r.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if r.MaxBackoff > 0 && wait > r.MaxBackoff {
+		wait = r.MaxBackoff
+	}
+	return wait
+}
+
+// shouldRetry reports if a request which received the status code, or no response at all when
+// statusCode is zero, can be attempted again
+func shouldRetry(statusCode int, idempotent bool) bool {
+	switch {
+	case statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable:
+		return true
+	case statusCode == 0 || statusCode >= 500:
+		return idempotent
+	}
+	return false
+}
+
+// do sends the request with api.client, retrying according to api.Retry. Each attempt is bounded by
+// api.RequestTimeout, and waiting between attempts stops as soon as ctx is done.
+func (api *APIClient) do(ctx context.Context, req *http.Request, idempotent bool) (*http.Response, error) {
+	attempts := api.Retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		res, err := api.attempt(ctx, req)
+
+		statusCode := 0
+		if err == nil {
+			statusCode = res.StatusCode
+			if !shouldRetry(statusCode, idempotent) || attempt >= attempts {
+				return res, nil
+			}
+		} else {
+			lastErr = err
+			if ctx.Err() != nil || !shouldRetry(statusCode, idempotent) || attempt >= attempts {
+				return nil, err
+			}
+		}
+
+		wait := api.Retry.backoff(attempt)
+		if res != nil {
+			wait = retryAfter(res, wait, api.Retry.MaxBackoff)
+			drain(res)
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w after: %v", ctx.Err(), lastErr)
+			}
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// attempt sends a copy of req bound to ctx and the per-attempt timeout. The timeout is released
+// when the response body is closed.
+func (api *APIClient) attempt(ctx context.Context, req *http.Request) (*http.Response, error) {
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if api.RequestTimeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, api.RequestTimeout)
+	}
+
+	r := req.Clone(attemptCtx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	res, err := api.client.Do(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if res.Body == nil {
+		res.Body = http.NoBody
+	}
+	if res.Request == nil {
+		res.Request = r
+	}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+
+	return res, nil
+}
+
+// cancelOnClose releases a request context once its response body has been read
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// retryAfter honors a Retry-After header given in seconds, capped at maxWait when it is set,
+// otherwise returning wait
+func retryAfter(res *http.Response, wait, maxWait time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return wait
+	}
+
+	wait = time.Duration(seconds) * time.Second
+	if maxWait > 0 && wait > maxWait {
+		wait = maxWait
+	}
+	return wait
+}
+
+// drain reads and closes a response body which will not be used so the connection can be reused
+func drain(res *http.Response) {
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+}
+
+// poll calls check every interval until it reports done, returns an error, or the timeout passes.
+// ErrPollTimeout is returned when check would next run after the timeout.
+func poll(ctx context.Context, interval, timeout time.Duration, check func(context.Context) (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		done, err := check(ctx)
+		if err != nil || done {
+			return err
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return ErrPollTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
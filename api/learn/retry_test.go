@@ -0,0 +1,208 @@
+package learn
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gSchool/glearn-cli/api"
+)
+
+const releasesResponseBody = `{"releases":[{"id":1,"branch_name":"master","status":"success"}]}`
+
+// retryTestAPI creates an APIClient with credentials already set, retrying quickly
+func retryTestAPI(mockClient *api.MockClient) *APIClient {
+	return &APIClient{
+		client:      mockClient,
+		baseURL:     "https://example.com",
+		Credentials: &Credentials{APIToken: &APIToken{token: "apiToken"}},
+		Retry:       RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	}
+}
+
+func Test_do_RetriesIdempotentRequests(t *testing.T) {
+	mockClient := api.MockResponses("", "", releasesResponseBody)
+	mockClient.StatusCodes = []int{503, 500, 200}
+	API := retryTestAPI(mockClient)
+
+	releases, err := API.GetBlockReleases(context.Background(), 1)
+	if err != nil {
+		t.Errorf("GetBlockReleases should succeed after retrying, got %s", err)
+	}
+	if len(releases) != 1 {
+		t.Errorf("GetBlockReleases should return the release from the final attempt, got %v", releases)
+	}
+	if len(mockClient.Requests) != 3 {
+		t.Errorf("GetBlockReleases should make 3 attempts, made %d", len(mockClient.Requests))
+	}
+}
+
+func Test_do_StopsAfterMaxAttempts(t *testing.T) {
+	mockClient := api.MockResponses("", "", "", "")
+	mockClient.StatusCodes = []int{500, 500, 500, 500}
+	API := retryTestAPI(mockClient)
+
+	_, err := API.GetBlockReleases(context.Background(), 1)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Errorf("GetBlockReleases should report the last response status, got %v", err)
+	}
+	if len(mockClient.Requests) != 3 {
+		t.Errorf("GetBlockReleases should stop after 3 attempts, made %d", len(mockClient.Requests))
+	}
+}
+
+func Test_do_RetriesNonIdempotentRequestsOnlyWhenUnprocessed(t *testing.T) {
+	mockClient := api.MockResponses("", `{"release_id":2}`)
+	mockClient.StatusCodes = []int{429, 200}
+	API := retryTestAPI(mockClient)
+
+	releaseID, err := API.CreateBranchRelease(context.Background(), 1, "master")
+	if err != nil || releaseID != 2 {
+		t.Errorf("CreateBranchRelease should succeed after a 429, got %d and %v", releaseID, err)
+	}
+
+	mockClient = api.MockResponses("", `{"release_id":2}`)
+	mockClient.StatusCodes = []int{500, 200}
+	API = retryTestAPI(mockClient)
+
+	_, err = API.CreateBranchRelease(context.Background(), 1, "master")
+	if err == nil {
+		t.Errorf("CreateBranchRelease should not be retried after a 500")
+	}
+	if len(mockClient.Requests) != 1 {
+		t.Errorf("CreateBranchRelease should make 1 attempt after a 500, made %d", len(mockClient.Requests))
+	}
+}
+
+func Test_do_StopsWhenCancelled(t *testing.T) {
+	mockClient := api.MockResponses("", "", "")
+	mockClient.StatusCodes = []int{503, 503, 503}
+	API := retryTestAPI(mockClient)
+	API.Retry.InitialBackoff = time.Hour
+	API.Retry.MaxBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := API.GetBlockReleases(ctx, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetBlockReleases should return context.Canceled, got %v", err)
+	}
+	if len(mockClient.Requests) != 1 {
+		t.Errorf("GetBlockReleases should not retry once cancelled, made %d attempts", len(mockClient.Requests))
+	}
+}
+
+// errClient fails every request with err
+type errClient struct {
+	err      error
+	requests int
+}
+
+func (c *errClient) Do(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return nil, c.err
+}
+
+func Test_do_ReturnsCancellationAfterNetworkError(t *testing.T) {
+	client := &errClient{err: errors.New("connection refused")}
+	API := retryTestAPI(nil)
+	API.client = client
+	API.Retry.InitialBackoff = time.Hour
+	API.Retry.MaxBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := API.GetBlockReleases(ctx, 1)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("GetBlockReleases should return context.Canceled with the last error, got %v", err)
+	}
+	if client.requests != 1 {
+		t.Errorf("GetBlockReleases should not retry once cancelled, made %d attempts", client.requests)
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	res := &http.Response{Header: http.Header{}}
+	if got := retryAfter(res, time.Second, 8*time.Second); got != time.Second {
+		t.Errorf("retryAfter should return the backoff without a header, got %s", got)
+	}
+
+	res.Header.Set("Retry-After", "3")
+	if got := retryAfter(res, time.Second, 8*time.Second); got != 3*time.Second {
+		t.Errorf("retryAfter should honor the header, got %s", got)
+	}
+
+	res.Header.Set("Retry-After", "3600")
+	if got := retryAfter(res, time.Second, 8*time.Second); got != 8*time.Second {
+		t.Errorf("retryAfter should be capped at the max backoff, got %s", got)
+	}
+}
+
+func Test_RetryPolicy_backoff(t *testing.T) {
+	r := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for retry, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := r.backoff(retry); got != expected {
+			t.Errorf("backoff(%d) should be %s, got %s", retry, expected, got)
+		}
+	}
+}
+
+func Test_shouldRetry(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		idempotent bool
+		expected   bool
+	}{
+		{0, true, true},
+		{0, false, false},
+		{500, true, true},
+		{500, false, false},
+		{503, false, true},
+		{429, false, true},
+		{404, true, false},
+		{200, true, false},
+	}
+	for _, tt := range tests {
+		if got := shouldRetry(tt.statusCode, tt.idempotent); got != tt.expected {
+			t.Errorf("shouldRetry(%d, %t) should be %t", tt.statusCode, tt.idempotent, tt.expected)
+		}
+	}
+}
+
+func Test_poll(t *testing.T) {
+	checks := 0
+	err := poll(context.Background(), time.Millisecond, time.Second, func(ctx context.Context) (bool, error) {
+		checks++
+		return checks == 3, nil
+	})
+	if err != nil || checks != 3 {
+		t.Errorf("poll should check until done, got %d checks and %v", checks, err)
+	}
+
+	checks = 0
+	err = poll(context.Background(), time.Hour, time.Minute, func(ctx context.Context) (bool, error) {
+		checks++
+		return false, nil
+	})
+	if err != ErrPollTimeout {
+		t.Errorf("poll should return ErrPollTimeout, got %v", err)
+	}
+	if checks != 1 {
+		t.Errorf("poll should not check again after the timeout, got %d checks", checks)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = poll(ctx, time.Hour, 2*time.Hour, func(ctx context.Context) (bool, error) {
+		return false, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("poll should stop when cancelled, got %v", err)
+	}
+}
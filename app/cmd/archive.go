@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// PreviewCompression is the flag for how much preview archives are compressed
+var PreviewCompression string
+
+// compressionLevels are the values of --compression. Previews default to fast, as the archive is
+// thrown away once Learn has built it.
+var compressionLevels = map[string]int{
+	"none":    flate.NoCompression,
+	"fast":    flate.BestSpeed,
+	"default": flate.DefaultCompression,
+	"best":    flate.BestCompression,
+}
+
+// storedExts are formats which are already compressed, so they are stored in the archive as they
+// are rather than compressed again
+var storedExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true,
+	".zip": true, ".gz": true, ".tgz": true, ".mp3": true, ".mp4": true, ".mov": true,
+	".woff": true, ".woff2": true,
+}
+
+// archiveEntry is a file or directory in the preview archive. Files are read and compressed as
+// the archive is written.
+type archiveEntry struct {
+	header zip.FileHeader
+	// path is the file on disk, empty for directories and generated files
+	path string
+	// generated is the contents of a file made for the archive, such as an autoconfig, rather than
+	// read from path
+	generated []byte
+}
+
+// isDir reports if the entry is a directory, which has no contents to compress
+func (e *archiveEntry) isDir() bool {
+	return e.path == "" && e.generated == nil
+}
+
+// compressionLevel returns the flate level for a --compression value
+func compressionLevel(name string) (int, error) {
+	level, ok := compressionLevels[name]
+	if !ok {
+		return 0, fmt.Errorf("--compression must be none, fast, default or best, got '%s'", name)
+	}
+	return level, nil
+}
+
+// compress reads the entry's file and compresses it with fw, or stores it as it is when store is
+// true or compressing would not make it any smaller. The header is completed for the contents
+// returned, which are as they are stored in the archive.
+func (e *archiveEntry) compress(fw *flate.Writer, store bool) ([]byte, error) {
+	contents := e.generated
+	if contents == nil {
+		var err error
+		if contents, err = ioutil.ReadFile(e.path); err != nil {
+			return nil, err
+		}
+	}
+	e.header.CRC32 = crc32.ChecksumIEEE(contents)
+	e.header.UncompressedSize64 = uint64(len(contents))
+	e.header.Method = zip.Store
+	data := contents
+
+	if !store && !storedExts[strings.ToLower(filepath.Ext(e.header.Name))] {
+		var buf bytes.Buffer
+		fw.Reset(&buf)
+		if _, err := fw.Write(contents); err != nil {
+			return nil, err
+		}
+		if err := fw.Close(); err != nil {
+			return nil, err
+		}
+		if buf.Len() < len(contents) {
+			e.header.Method, data = zip.Deflate, buf.Bytes()
+		}
+	}
+	e.header.CompressedSize64 = uint64(len(data))
+	return data, nil
+}
+
+// compressed is the result of compressing the entry at index i
+type compressed struct {
+	data []byte
+	err  error
+}
+
+// writeArchive writes the zip of entries to w and returns its size. Files are compressed by a
+// worker for each CPU and written in order as they are ready. Only a few files per worker are
+// held in memory at once, however large the block. Writing stops at the first error or when ctx
+// is cancelled.
+func writeArchive(ctx context.Context, w io.Writer, entries []*archiveEntry, level int) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	// the workers are stopped and finished with the entries before they are returned to the caller
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	workers := runtime.NumCPU()
+	// results are buffered so a worker never waits for the archive to catch up, while inFlight
+	// bounds how far ahead of the archive the workers get
+	results := make([]chan compressed, len(entries))
+	for i := range results {
+		results[i] = make(chan compressed, 1)
+	}
+	inFlight := make(chan struct{}, 2*workers)
+	work := make(chan int)
+
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fw, _ := flate.NewWriter(nil, level)
+			for i := range work {
+				var c compressed
+				if !entries[i].isDir() {
+					c.data, c.err = entries[i].compress(fw, level == flate.NoCompression)
+				}
+				results[i] <- c
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(work)
+		for i := range entries {
+			select {
+			case inFlight <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case work <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	cw := &countingWriter{w: w}
+	archive := zip.NewWriter(cw)
+	for i, e := range entries {
+		var c compressed
+		select {
+		case c = <-results[i]:
+		case <-ctx.Done():
+			return cw.n, ctx.Err()
+		}
+		<-inFlight
+		if c.err != nil {
+			return cw.n, c.err
+		}
+
+		// the zip writer takes ownership of the header, so the entry's is left as it is
+		header := e.header
+		writer, err := archive.CreateRaw(&header)
+		if err != nil {
+			return cw.n, err
+		}
+		if _, err := writer.Write(c.data); err != nil {
+			return cw.n, err
+		}
+	}
+	err := archive.Close()
+	return cw.n, err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
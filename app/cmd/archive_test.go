@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archiveFixture writes a small block and returns the entries of its archive, uncompressed
+func archiveFixture(t *testing.T) []*archiveEntry {
+	files := map[string]string{
+		"units/intro.md": strings.Repeat("# Intro\n\nSome lesson text.\n", 100),
+		"units/logo.png": strings.Repeat("not really a png", 10),
+		"units/tiny.md":  "a",
+	}
+	block := writeBlock(t, files)
+
+	entries := []*archiveEntry{{header: zip.FileHeader{Name: "block/units/"}}}
+	for _, name := range []string{"units/intro.md", "units/logo.png", "units/tiny.md"} {
+		entries = append(entries, &archiveEntry{header: zip.FileHeader{Name: "block/" + name}, path: filepath.Join(block, name)})
+	}
+	return entries
+}
+
+// readArchive returns the contents of each file in a zip, keyed by name
+func readArchive(t *testing.T, b []byte) map[string]string {
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("the archive could not be read: %s", err)
+	}
+	contents := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("%s could not be opened: %s", f.Name, err)
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("%s could not be read: %s", f.Name, err)
+		}
+		contents[f.Name] = string(data)
+	}
+	return contents
+}
+
+func Test_writeArchive(t *testing.T) {
+	entries := archiveFixture(t)
+	var archive bytes.Buffer
+	size, err := writeArchive(context.Background(), &archive, entries, flate.BestSpeed)
+	if err != nil || size != int64(archive.Len()) {
+		t.Fatalf("writeArchive should return the size written, got %d of %d and %v", size, archive.Len(), err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(archive.Bytes()), size)
+	if err != nil {
+		t.Fatalf("the archive could not be read: %s", err)
+	}
+	for i, method := range []uint16{zip.Store, zip.Deflate, zip.Store, zip.Store} {
+		if r.File[i].Name != entries[i].header.Name || r.File[i].Method != method {
+			t.Errorf("%s should be written in order with method %d, got %s with %d", entries[i].header.Name, method, r.File[i].Name, r.File[i].Method)
+		}
+	}
+	contents := readArchive(t, archive.Bytes())
+	if len(contents) != 4 || contents["block/units/tiny.md"] != "a" || !strings.HasPrefix(contents["block/units/intro.md"], "# Intro") {
+		t.Errorf("the archive should hold the directory and every file as it was, got %q", contents)
+	}
+
+	// more files than are compressed ahead of the archive at once are still written in order
+	files := map[string]string{}
+	for i := 0; i < 200; i++ {
+		files[fmt.Sprintf("units/%03d.md", i)] = strings.Repeat(fmt.Sprintf("lesson %d\n", i), i)
+	}
+	block := writeBlock(t, files)
+	many := []*archiveEntry{}
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("units/%03d.md", i)
+		many = append(many, &archiveEntry{header: zip.FileHeader{Name: name}, path: filepath.Join(block, name)})
+	}
+	archive.Reset()
+	if _, err := writeArchive(context.Background(), &archive, many, flate.BestSpeed); err != nil {
+		t.Fatalf("writeArchive errored: %s", err)
+	}
+	r, _ = zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	for i, f := range r.File {
+		if f.Name != many[i].header.Name {
+			t.Fatalf("file %d should be %s, got %s", i, many[i].header.Name, f.Name)
+		}
+	}
+	if contents := readArchive(t, archive.Bytes()); contents["units/150.md"] != files["units/150.md"] {
+		t.Errorf("every file should be written with its own contents")
+	}
+
+	archive.Reset()
+	if _, err := writeArchive(context.Background(), &archive, archiveFixture(t), flate.NoCompression); err != nil {
+		t.Errorf("writeArchive errored: %s", err)
+	}
+	if r, _ := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len())); r == nil || r.File[1].Method != zip.Store {
+		t.Errorf("with no compression every file should be stored")
+	}
+
+	missing := append(archiveFixture(t), &archiveEntry{header: zip.FileHeader{Name: "block/gone.md"}, path: "gone.md"})
+	if _, err := writeArchive(context.Background(), ioutil.Discard, missing, flate.BestSpeed); err == nil {
+		t.Errorf("writeArchive should return the error reading a file")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := writeArchive(ctx, ioutil.Discard, archiveFixture(t), flate.BestSpeed); err != context.Canceled {
+		t.Errorf("writeArchive should stop when cancelled, got %v", err)
+	}
+}
+
+func Test_compressionLevel(t *testing.T) {
+	if level, err := compressionLevel("fast"); err != nil || level != flate.BestSpeed {
+		t.Errorf("fast should be the fastest flate level, got %d %v", level, err)
+	}
+	if _, err := compressionLevel("ultra"); err == nil {
+		t.Errorf("an unknown compression should error")
+	}
+}
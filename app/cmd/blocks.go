@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/spf13/cobra"
+)
+
+// BlockOrg, BlockRepo and BlockOrigin are the flags identifying the block for the blocks find command
+var (
+	BlockOrg    string
+	BlockRepo   string
+	BlockOrigin string
+)
+
+// blockDetails is a Block along with its url on Learn, used for printing
+type blockDetails struct {
+	learn.Block
+	URL string `json:"url"`
+}
+
+var blocksCmd = &cobra.Command{
+	Use:   "blocks",
+	Short: "Inspect blocks on Learn",
+	Long: `
+Shows a block's metadata, its current sync errors, and the cohorts using it.
+Check how many cohorts will receive a change before publishing it.
+	`,
+}
+
+var blocksShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the block for the current repository",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		if _, err := currentProfile(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		setupLearnAPI(ctx, false)
+
+		block, err := currentBlock(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, learnError(err))
+			os.Exit(1)
+		}
+
+		err = printBlock(resultOut, block, Output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var blocksFindCmd = &cobra.Command{
+	Use:   "find --org=<org> --repo=<repo>",
+	Short: "Show the block for any repository",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		if BlockOrg == "" || BlockRepo == "" {
+			fmt.Fprintln(os.Stderr, "The find command needs both '--org' and '--repo' flags.\n\nUse: learn blocks find --org=gSchool --repo=my-block")
+			os.Exit(1)
+		}
+
+		if _, err := currentProfile(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		setupLearnAPI(ctx, false)
+
+		repoPieces := learn.RepoPieces{Origin: BlockOrigin, Org: BlockOrg, RepoName: strings.TrimSuffix(BlockRepo, ".git")}
+		block, err := learn.API.GetBlockByRepoName(ctx, repoPieces)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching block from learn: %s\n", learnError(err))
+			os.Exit(1)
+		}
+		if !block.Exists() {
+			fmt.Fprintf(os.Stderr, "No block found on Learn for %s/%s/%s\n", repoPieces.Origin, repoPieces.Org, repoPieces.RepoName)
+			os.Exit(1)
+		}
+
+		err = printBlock(resultOut, block, Output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// printBlock writes the block to w in the given format, either text or json
+func printBlock(w io.Writer, block learn.Block, format string) error {
+	details := blockDetails{
+		Block: block,
+		URL:   fmt.Sprintf("%s/blocks/%d", learn.API.BaseURL(), block.ID),
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(details)
+	}
+
+	fmt.Fprintf(w, "Block %d: %s\n", details.ID, details.Title)
+	fmt.Fprintf(w, "Repo:    %s/%s/%s\n", details.Origin, details.Org, details.RepoName)
+	fmt.Fprintf(w, "URL:     %s\n", details.URL)
+
+	if len(details.CohortsUsing) == 0 {
+		fmt.Fprintln(w, "\nNo cohorts are using this block")
+	} else {
+		ids := make([]string, len(details.CohortsUsing))
+		for i, id := range details.CohortsUsing {
+			ids[i] = fmt.Sprint(id)
+		}
+		fmt.Fprintf(w, "\nUsed by %d cohorts: %s\n", len(ids), strings.Join(ids, ", "))
+	}
+
+	if len(details.SyncErrors) == 0 {
+		fmt.Fprintln(w, "No sync errors")
+	} else {
+		fmt.Fprintf(w, "\n%d sync errors:\n", len(details.SyncErrors))
+		for _, e := range details.SyncErrors {
+			fmt.Fprintf(w, "  %s\n", e)
+		}
+	}
+
+	return nil
+}
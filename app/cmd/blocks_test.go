@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gSchool/glearn-cli/api"
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/spf13/viper"
+)
+
+func Test_printBlock(t *testing.T) {
+	viper.Set("api_token", "apiToken")
+	learn.API, _ = learn.NewAPI(context.Background(), "https://example.com", "apiToken", api.MockResponse(credentialsResponseBody), false)
+
+	block := learn.Block{
+		ID:           4,
+		Title:        "Blocks Test",
+		Origin:       "github.com",
+		Org:          "gSchool",
+		RepoName:     "blocks-test",
+		SyncErrors:   []string{"unit missing"},
+		CohortsUsing: []int{7, 9},
+	}
+
+	var out bytes.Buffer
+	if err := printBlock(&out, block, "text"); err != nil {
+		t.Errorf("printBlock errored: %s", err)
+	}
+	for _, expected := range []string{"Block 4: Blocks Test", "github.com/gSchool/blocks-test", "https://example.com/blocks/4", "Used by 2 cohorts: 7, 9", "unit missing"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("text output should contain '%s', was:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := printBlock(&out, block, "json"); err != nil {
+		t.Errorf("printBlock errored: %s", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("json output should be valid json: %s\n%s", err, out.String())
+	}
+	if decoded["url"] != "https://example.com/blocks/4" || decoded["repo_name"] != "blocks-test" {
+		t.Errorf("json output should include the block fields and url, was:\n%s", out.String())
+	}
+	if cohorts, ok := decoded["cohorts_using"].([]interface{}); !ok || len(cohorts) != 2 {
+		t.Errorf("json output should list cohorts using the block, was:\n%s", out.String())
+	}
+}
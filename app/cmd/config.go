@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -38,6 +37,8 @@ type ConfigBuilder struct {
 	unitsDir            string
 	unitsDirName        string
 	unitsRootDirName    string
+	// ignore is the .learnignore files of the block, lessons they match are left out
+	ignore *learnIgnore
 }
 
 // Note: struct fields must be public in order for unmarshal to
@@ -66,7 +67,8 @@ type ContentFileAttrs struct {
 	fromHeader               bool   // fromHeader is set true when the attrs were parsed from the header
 }
 
-var gitTopLevelCmd = "git rev-parse --show-toplevel"
+// autoConfigFile is the config generated for a block without a config.yaml
+const autoConfigFile = "autoconfig.yaml"
 
 // only used from publish, just going to send
 func publishFindOrCreateConfig(target string) (bool, error) {
@@ -74,19 +76,23 @@ func publishFindOrCreateConfig(target string) (bool, error) {
 	return cb.findOrCreateConfig()
 }
 
-// previewFindOrCreateConfig ensures a config for the previewed curriculum exists to be read by Learn. Because
+// previewFindOrCreateConfig generates an autoconfig for previewed curriculum without a config, returning nil
+// when it has one. The autoconfig is not written to the target, it is added to the preview archive. Because
 // docker directory paths can contain content that looks like lesson curriculum, they are passed as arguments to
 // prevent those directories from being included as lesson content in a generated config file.
-func previewFindOrCreateConfig(target string, isSingleFilePreview bool, excludePaths []string) (bool, error) {
+func previewFindOrCreateConfig(target string, isSingleFilePreview bool, excludePaths []string) ([]byte, error) {
 	cb := NewConfigBuilder(target, isSingleFilePreview, false, excludePaths)
-	return cb.findOrCreateConfig()
+	if cb.ConfigExists() {
+		return nil, nil
+	}
+	return cb.autoConfigYaml()
 }
 
 func NewConfigBuilder(target string, isSingleFilePreview, publishContext bool, excludePaths []string) *ConfigBuilder {
 	// Make sure we have an ending slash on the root dir
 	blockRoot := ""
 	if publishContext {
-		target, _ = GitTopLevelDir()
+		target, _ = gitRepo.TopLevelDir()
 		blockRoot = target + "/"
 	} else {
 		if strings.HasSuffix(target, "/") {
@@ -98,7 +104,7 @@ func NewConfigBuilder(target string, isSingleFilePreview, publishContext bool, e
 
 	// UnitsDirectory supplied from a string flag
 	unitsDirectory := UnitsDirectory
-	if target == tmpSingleFileDir {
+	if filepath.Base(target) == tmpSingleFileDir {
 		unitsDirectory = "."
 	}
 
@@ -117,7 +123,14 @@ func NewConfigBuilder(target string, isSingleFilePreview, publishContext bool, e
 		unitsRootDirName = unitsDirectory
 	}
 
+	ignore, err := loadLearnIgnore(blockRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: Could not read the %s files in %s, nothing is ignored. Err: %v\n", learnIgnoreFile, blockRoot, err)
+		ignore = nil
+	}
+
 	return &ConfigBuilder{
+		ignore:              ignore,
 		target:              target,
 		isSingleFilePreview: isSingleFilePreview,
 		publishContext:      publishContext,
@@ -180,39 +193,26 @@ func (cb *ConfigBuilder) findOrCreateConfig() (bool, error) {
 	return true, nil
 }
 
-// createYamlConfig determines the context for creating a new config yaml and handles file creation and encoding
+// createYamlConfig writes the autoconfig.yaml to the block root, replacing any there already
 func (cb *ConfigBuilder) createYamlConfig() error {
-	// The config file location that we will be creating
-	autoConfigYamlPath := cb.blockRoot + "autoconfig.yaml"
-
-	// Remove the existing one if its around
-	_, err := os.Stat(autoConfigYamlPath)
-	if err == nil {
-		os.Remove(autoConfigYamlPath)
-	}
-
-	// Create tmpSingleFileDir if it does not exist
-	if _, err := os.Stat(tmpSingleFileDir); os.IsNotExist(err) {
-		os.Mkdir(tmpSingleFileDir, os.FileMode(0777))
-	}
-
-	configFile, err := os.Create(autoConfigYamlPath)
+	autoConfig, err := cb.autoConfigYaml()
 	if err != nil {
 		return err
 	}
-	configFile.WriteString(autoComment)
-	defer configFile.Sync()
-	defer configFile.Close()
-
-	encoder := yaml.NewEncoder(configFile)
-	defer encoder.Close()
+	return ioutil.WriteFile(cb.blockRoot+autoConfigFile, autoConfig, 0666)
+}
 
+// autoConfigYaml generates the contents of an autoconfig.yaml, with the comment explaining it at the top
+func (cb *ConfigBuilder) autoConfigYaml() ([]byte, error) {
 	autoConfig, err := cb.newConfigYaml()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	encoder.Encode(autoConfig)
-	return nil
+	b, err := yaml.Marshal(autoConfig)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(autoComment), b...), nil
 }
 
 // newConfigYaml creates a ConfigYaml struct given certain conditions
@@ -227,7 +227,7 @@ func (cb *ConfigBuilder) newConfigYaml() (ConfigYaml, error) {
 		return config, err
 	}
 	if len(unitToContentFileMap) == 0 {
-		return config, fmt.Errorf("No content found at '%s'. Preview of an individual unit is not supported, make sure '%s' is the root of a repo or a single lesson.", cb.target, cb.target)
+		return config, fmt.Errorf("No content found at '%s'. Make sure '%s' is the root of a block, a unit or lessons.", cb.target, cb.target)
 	}
 
 	// sort unit keys in lexicographical order
@@ -287,33 +287,7 @@ func (cb *ConfigBuilder) newConfigYaml() (ConfigYaml, error) {
 				} else {
 					contentFile.Path = "/" + contentFile.Path
 				}
-				if contentFile.fromHeader {
-					// when it came from the header but Type is not set, fall back to detecting from path
-					if contentFile.Type == "" {
-						contentFile.Type = detectContentType(contentFile.Path)
-					}
-					// when it came from the header but UID is not set, fall back to detecting from path
-					if contentFile.UID == "" {
-						cfUID := []byte(standard.Title + contentFile.Path)
-						md5cfUID := md5.Sum(cfUID)
-						contentFile.UID = hex.EncodeToString(md5cfUID[:])
-					}
-					// when it came from the header but DefaultVisibility is not set, fall back to detecting from path
-					if contentFile.DefaultVisibility == "" && strings.Contains(strings.ToLower(contentFile.Path), "hidden") {
-						contentFile.DefaultVisibility = "hidden"
-					}
-					standard.ContentFiles = append(standard.ContentFiles, contentFile)
-				} else {
-					cfUID := []byte(standard.Title + contentFile.Path)
-					md5cfUID := md5.Sum(cfUID)
-
-					contentFile.Type = detectContentType(contentFile.Path)
-					contentFile.UID = hex.EncodeToString(md5cfUID[:])
-					if strings.Contains(strings.ToLower(contentFile.Path), "hidden") {
-						contentFile.DefaultVisibility = "hidden"
-					}
-					standard.ContentFiles = append(standard.ContentFiles, contentFile)
-				}
+				standard.ContentFiles = append(standard.ContentFiles, contentFileDefaults(standard.Title, contentFile))
 			}
 		}
 		config.Standards = append(config.Standards, standard)
@@ -322,6 +296,75 @@ func (cb *ConfigBuilder) newConfigYaml() (ConfigYaml, error) {
 	return config, nil
 }
 
+// contentFileDefaults fills in the Type, UID and DefaultVisibility of a content file in the standard
+// titled standardTitle. Attributes set in the file's header are kept, otherwise they are detected from
+// the path.
+func contentFileDefaults(standardTitle string, contentFile ContentFileAttrs) ContentFileAttrs {
+	if contentFile.fromHeader {
+		// when it came from the header but Type is not set, fall back to detecting from path
+		if contentFile.Type == "" {
+			contentFile.Type = detectContentType(contentFile.Path)
+		}
+		// when it came from the header but UID is not set, fall back to detecting from path
+		if contentFile.UID == "" {
+			cfUID := []byte(standardTitle + contentFile.Path)
+			md5cfUID := md5.Sum(cfUID)
+			contentFile.UID = hex.EncodeToString(md5cfUID[:])
+		}
+		// when it came from the header but DefaultVisibility is not set, fall back to detecting from path
+		if contentFile.DefaultVisibility == "" && strings.Contains(strings.ToLower(contentFile.Path), "hidden") {
+			contentFile.DefaultVisibility = "hidden"
+		}
+		return contentFile
+	}
+
+	cfUID := []byte(standardTitle + contentFile.Path)
+	md5cfUID := md5.Sum(cfUID)
+
+	contentFile.Type = detectContentType(contentFile.Path)
+	contentFile.UID = hex.EncodeToString(md5cfUID[:])
+	if strings.Contains(strings.ToLower(contentFile.Path), "hidden") {
+		contentFile.DefaultVisibility = "hidden"
+	}
+	return contentFile
+}
+
+// lessonsConfigYaml creates a config listing only the given lessons, with paths relative to blockRoot.
+// Each directory of lessons is a standard, described by its description.yaml when it has one.
+func lessonsConfigYaml(blockRoot string, lessons []string) (ConfigYaml, error) {
+	config := ConfigYaml{Standards: []Standard{}}
+
+	sorted := append([]string{}, lessons...)
+	sort.Strings(sorted)
+
+	standards := map[string]int{}
+	for _, lesson := range sorted {
+		contentFile, err := readContentFileAttrs(filepath.ToSlash(lesson), filepath.Join(blockRoot, lesson))
+		if err != nil {
+			return config, err
+		}
+		contentFile.Path = "/" + contentFile.Path
+
+		unit := filepath.Dir(lesson)
+		i, ok := standards[unit]
+		if !ok {
+			var standard Standard
+			if unit == "." {
+				abs, _ := filepath.Abs(blockRoot)
+				standard = standardFromUnit(filepath.Base(abs))
+			} else {
+				standard = newStandard(filepath.Join(blockRoot, filepath.Dir(unit)), filepath.Base(unit))
+			}
+			i = len(config.Standards)
+			standards[unit] = i
+			config.Standards = append(config.Standards, standard)
+		}
+		config.Standards[i].ContentFiles = append(config.Standards[i].ContentFiles, contentFileDefaults(config.Standards[i].Title, contentFile))
+	}
+
+	return config, nil
+}
+
 func detectContentType(p string) string {
 	fullpath := strings.ToLower(p)
 	parts := strings.Split(fullpath, "/")
@@ -493,6 +536,9 @@ func (cb *ConfigBuilder) buildUnitToContentFileMap() (map[string][]ContentFileAt
 			if info.Mode().IsRegular() && strings.HasSuffix(info.Name(), ".md") {
 				readPath := cb.blockRoot + cb.unitsRootDirName + "/" + info.Name()
 				path := cb.unitsRootDirName + "/" + info.Name()
+				if cb.ignore.ignored(path, false) {
+					continue
+				}
 				contentFile, err := readContentFileAttrs(path, readPath)
 				if err != nil {
 					return unitToContentFileMap, err
@@ -530,6 +576,12 @@ func (cb *ConfigBuilder) buildUnitToContentFileMap() (map[string][]ContentFileAt
 					if err != nil {
 						return err
 					}
+					if rel, err := filepath.Rel(cb.blockRoot, path); err == nil && cb.ignore.ignored(rel, info.IsDir()) {
+						if info.IsDir() {
+							return filepath.SkipDir
+						}
+						return nil
+					}
 
 					if len(cb.blockRoot) > 0 && len(path) > len(cb.blockRoot) && strings.HasSuffix(path, ".md") {
 						localPath := path
@@ -556,16 +608,6 @@ func (cb *ConfigBuilder) buildUnitToContentFileMap() (map[string][]ContentFileAt
 	return unitToContentFileMap, nil
 }
 
-// get the root dir of the git project
-func GitTopLevelDir() (string, error) {
-	out, err := exec.Command("bash", "-c", gitTopLevelCmd).CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("%s", out)
-	}
-
-	return strings.TrimSpace(string(out)), err
-}
-
 // newStandard returns a standard from tne unitDir and unit name combination
 // unitDir is the location of the individual unit, with unit the directory beneath it
 // Either a description yaml file is read from, or the unit name is used to build a standard
@@ -6,15 +6,16 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/gSchool/glearn-cli/git"
 	yaml "gopkg.in/yaml.v2"
 )
 
 const withConfigFixture = "../../fixtures/test-block-with-config"
 
 func Test_PreviewDetectsConfig(t *testing.T) {
-	createdConfig, _ := previewFindOrCreateConfig(withConfigFixture, false, []string{})
+	autoConfig, _ := previewFindOrCreateConfig(withConfigFixture, false, []string{})
 
-	if createdConfig {
+	if autoConfig != nil {
 		t.Errorf("Created a config when one existed")
 	}
 }
@@ -22,7 +23,7 @@ func Test_PreviewDetectsConfig(t *testing.T) {
 const withNoConfigFixture = "../../fixtures/test-block-no-config"
 
 func Test_PublishBuildsAutoConfig(t *testing.T) {
-	gitTopLevelCmd = "echo ../../fixtures/test-block-no-config"
+	gitRepo = &git.Fake{TopLevel: "../../fixtures/test-block-no-config"}
 	createdConfig, err := publishFindOrCreateConfig(withNoConfigFixture)
 	if err != nil {
 		t.Errorf("Should not have errored but got error: '%s'\n", err)
@@ -168,18 +169,13 @@ const withNoUnitsDirFixture = "../../fixtures/test-block-no-units-dir"
 
 func Test_PreviewBuildsAutoConfigDeclaredUnitsDir(t *testing.T) {
 	UnitsDirectory = "foo"
-	createdConfig, _ := previewFindOrCreateConfig(withNoUnitsDirFixture, false, []string{})
-	if createdConfig == false {
-		t.Errorf("Should of created a config file")
+	autoConfig, _ := previewFindOrCreateConfig(withNoUnitsDirFixture, false, []string{})
+	if autoConfig == nil {
+		t.Errorf("Should of created a config")
 	}
 	UnitsDirectory = ""
 
-	b, err := ioutil.ReadFile(withNoUnitsDirFixture + "/autoconfig.yaml")
-	if err != nil {
-		fmt.Print(err)
-	}
-
-	config := string(b)
+	config := string(autoConfig)
 
 	if !strings.Contains(config, "Title: Foo") {
 		t.Errorf("Autoconfig should have a unit title of Foo")
@@ -191,11 +187,11 @@ func Test_PreviewBuildsAutoConfigDeclaredUnitsDir(t *testing.T) {
 }
 
 func Test_PreviewBuildFailsWhenPreviewingSingleUnit(t *testing.T) {
-	gitTopLevelCmd = "echo ../../fixtures/test-block-no-units-dir"
-	createdConfig, err := previewFindOrCreateConfig(withNoUnitsDirFixture+"/single_unit", false, []string{})
+	gitRepo = &git.Fake{TopLevel: "../../fixtures/test-block-no-units-dir"}
+	autoConfig, err := previewFindOrCreateConfig(withNoUnitsDirFixture+"/single_unit", false, []string{})
 
-	if createdConfig == true {
-		t.Errorf("Should not of created a config file")
+	if autoConfig != nil {
+		t.Errorf("Should not of created a config")
 	}
 
 	if err == nil {
@@ -204,18 +200,13 @@ func Test_PreviewBuildFailsWhenPreviewingSingleUnit(t *testing.T) {
 }
 
 func Test_AutoConfigAddsInFileTypesOrVisibility(t *testing.T) {
-	gitTopLevelCmd = "echo ../../fixtures/test-block-no-config"
-	createdConfig, _ := previewFindOrCreateConfig(withNoConfigFixture, false, []string{})
-	if createdConfig == false {
-		t.Errorf("Should of created a config file")
+	gitRepo = &git.Fake{TopLevel: "../../fixtures/test-block-no-config"}
+	autoConfig, _ := previewFindOrCreateConfig(withNoConfigFixture, false, []string{})
+	if autoConfig == nil {
+		t.Errorf("Should of created a config")
 	}
 
-	b, err := ioutil.ReadFile(withNoConfigFixture + "/autoconfig.yaml")
-	if err != nil {
-		fmt.Print(err)
-	}
-
-	config := string(b)
+	config := string(autoConfig)
 
 	if !strings.Contains(config, "Type: Checkpoint") {
 		t.Errorf("Autoconfig should have a content path of checkpoint but the type should not of changed")
@@ -239,17 +230,12 @@ func Test_AutoConfigAddsInFileTypesOrVisibility(t *testing.T) {
 }
 
 func Test_IgnoresFilesAndUnitsThatStartWithTwoUnderscores(t *testing.T) {
-	createdConfig, _ := previewFindOrCreateConfig(withNoConfigFixture, false, []string{})
-	if createdConfig == false {
-		t.Errorf("Should of created a config file")
+	autoConfig, _ := previewFindOrCreateConfig(withNoConfigFixture, false, []string{})
+	if autoConfig == nil {
+		t.Errorf("Should of created a config")
 	}
 
-	b, err := ioutil.ReadFile(withNoConfigFixture + "/autoconfig.yaml")
-	if err != nil {
-		fmt.Print(err)
-	}
-
-	config := string(b)
+	config := string(autoConfig)
 
 	if strings.Contains(config, "__skip") {
 		t.Errorf("Autoconfig have units that start with __")
@@ -261,17 +247,12 @@ func Test_IgnoresFilesAndUnitsThatStartWithTwoUnderscores(t *testing.T) {
 }
 
 func Test_IgnoresExcludedFiles(t *testing.T) {
-	createdConfig, _ := previewFindOrCreateConfig(withNoConfigFixture, false, []string{"/units"})
-	if createdConfig == false {
-		t.Errorf("Should of created a config file")
-	}
-
-	b, err := ioutil.ReadFile(withNoConfigFixture + "/autoconfig.yaml")
-	if err != nil {
-		fmt.Print(err)
+	autoConfig, _ := previewFindOrCreateConfig(withNoConfigFixture, false, []string{"/units"})
+	if autoConfig == nil {
+		t.Errorf("Should of created a config")
 	}
 
-	config := string(b)
+	config := string(autoConfig)
 
 	if strings.Contains(config, "Title: Unit 1") {
 		t.Errorf("Autoconfig should have excluded a unit titled Unit 1")
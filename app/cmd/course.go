@@ -0,0 +1,419 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// CourseParallel is the flag for the number of repos published at once by the course publish command
+var CourseParallel int
+
+// CourseBranch is the flag for the branch released for each repo by the course publish command
+var CourseBranch string
+
+// CourseCheckPublished is the flag for the course validate command to confirm each repo is a block on Learn
+var CourseCheckPublished bool
+
+// allowed values for the optional course.yaml settings, the empty string uses Learn's default
+var (
+	validDefaultUpdates        = []string{"", "auto", "manual"}
+	validDefaultUnitVisibility = []string{"", "hidden"}
+)
+
+// CourseYaml is the shape of a course.yaml file, as scaffolded with `learn markdown courseyaml`
+type CourseYaml struct {
+	DefaultUnitVisibility string          `yaml:"DefaultUnitVisibility,omitempty"`
+	Course                []CourseSection `yaml:"Course"`
+}
+
+// CourseSection groups repos which are shown together on the curriculum homepage
+type CourseSection struct {
+	Section string       `yaml:"Section"`
+	Repos   []CourseRepo `yaml:"Repos"`
+}
+
+// CourseRepo is a block repo included in a course
+type CourseRepo struct {
+	URL            string `yaml:"URL"`
+	DefaultUpdates string `yaml:"DefaultUpdates,omitempty"`
+}
+
+// coursePublishResult is the outcome of publishing a single repo from a course.yaml
+type coursePublishResult struct {
+	URL        string
+	Repo       learn.RepoPieces
+	BlockID    int
+	ReleaseID  int
+	Created    bool
+	Warnings   []string
+	SyncErrors []string
+	Err        error
+}
+
+var courseCmd = &cobra.Command{
+	Use:   "course",
+	Short: "Work with the block repos listed in a course.yaml",
+	Long: `
+A course.yaml lists the block repos that make up a course. Generate one with
+'learn markdown courseyaml'.
+	`,
+}
+
+var coursePublishCmd = &cobra.Command{
+	Use:   "publish <course.yaml>",
+	Short: "Publish every block repo listed in a course.yaml",
+	Long: `
+Releases each block repo listed in a course.yaml on Learn, creating blocks which
+do not exist yet. Repos are not cloned or pushed; each release is built from the
+remote branch given with --branch. Releases are triggered and polled concurrently,
+and a summary for every repo is printed when they are finished.
+	`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		if _, err := currentProfile(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		course, err := readCourseYaml(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		setupLearnAPI(ctx, false)
+		fmt.Printf("Using Learn profile %s\n", learnProfile)
+
+		urls := course.repoURLs()
+		if len(urls) == 0 {
+			fmt.Fprintf(os.Stderr, "No repos found in %s\n", args[0])
+			os.Exit(1)
+		}
+		fmt.Printf("Publishing %d repos from %s on branch %s...\n", len(urls), args[0], CourseBranch)
+
+		results := publishCourseRepos(ctx, urls, CourseBranch, CourseParallel)
+		if printCoursePublishSummary(os.Stdout, results) {
+			os.Exit(1)
+		}
+	},
+}
+
+var courseValidateCmd = &cobra.Command{
+	Use:   "validate <course.yaml>",
+	Short: "Check a course.yaml for mistakes before a cohort syncs it",
+	Long: `
+Parses a course.yaml and checks its structure, the allowed values of
+DefaultUpdates and DefaultUnitVisibility, that every URL names an org and repo,
+and that no repo is listed more than once. With --published, Learn is also asked
+to confirm every repo is already a published block.
+	`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		data, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not read course file '%s'. Err: %v\n", args[0], err)
+			os.Exit(1)
+		}
+
+		course, problems := parseCourseYamlStrict(data)
+		problems = append(problems, course.validate()...)
+
+		if CourseCheckPublished && len(problems) == 0 {
+			if _, err := currentProfile(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			setupLearnAPI(ctx, false)
+			problems = append(problems, course.unpublishedRepos(ctx)...)
+		}
+
+		if len(problems) > 0 {
+			printCourseProblems(os.Stderr, args[0], problems)
+			os.Exit(1)
+		}
+
+		fmt.Printf("%s is valid: %d sections, %d repos\n", args[0], len(course.Course), len(course.repoURLs()))
+	},
+}
+
+// readCourseYaml reads and parses the course.yaml at path
+func readCourseYaml(path string) (CourseYaml, error) {
+	course := CourseYaml{}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return course, fmt.Errorf("Could not read course file '%s'. Err: %v", path, err)
+	}
+
+	err = yaml.Unmarshal(data, &course)
+	if err != nil {
+		return course, fmt.Errorf("Could not parse course file '%s'. Err: %v", path, err)
+	}
+
+	return course, nil
+}
+
+// parseCourseYamlStrict parses course.yaml data, reporting unknown or duplicated keys as problems.
+// When strict parsing fails the data is parsed again leniently, so the structure can still be validated.
+func parseCourseYamlStrict(data []byte) (CourseYaml, []string) {
+	course := CourseYaml{}
+	err := yaml.UnmarshalStrict(data, &course)
+	if err == nil {
+		return course, nil
+	}
+
+	problems := []string{fmt.Sprintf("Could not parse course.yaml: %v", err)}
+	course = CourseYaml{}
+	if yaml.Unmarshal(data, &course) != nil {
+		return CourseYaml{}, problems
+	}
+	return course, problems
+}
+
+// validate checks the course structure and setting values, and that each repo url can be resolved
+// to a single block. A description of each problem found is returned.
+func (c CourseYaml) validate() []string {
+	problems := []string{}
+
+	if !containsString(validDefaultUnitVisibility, c.DefaultUnitVisibility) {
+		problems = append(problems, fmt.Sprintf("DefaultUnitVisibility '%s' is not allowed, use 'hidden' or remove it", c.DefaultUnitVisibility))
+	}
+	if len(c.Course) == 0 {
+		problems = append(problems, "Course must list at least one Section")
+	}
+
+	// the section each repo was first found in, keyed by origin, org and repo name
+	firstSection := map[learn.RepoPieces]string{}
+	for i, section := range c.Course {
+		sectionName := section.Section
+		if strings.TrimSpace(sectionName) == "" {
+			sectionName = fmt.Sprintf("Course[%d]", i)
+			problems = append(problems, fmt.Sprintf("%s is missing a Section name", sectionName))
+		}
+		if len(section.Repos) == 0 {
+			problems = append(problems, fmt.Sprintf("Section '%s' has no Repos", sectionName))
+		}
+
+		for j, repo := range section.Repos {
+			location := fmt.Sprintf("Section '%s' Repos[%d]", sectionName, j)
+			if !containsString(validDefaultUpdates, repo.DefaultUpdates) {
+				problems = append(problems, fmt.Sprintf("%s DefaultUpdates '%s' is not allowed, use 'auto' or 'manual'", location, repo.DefaultUpdates))
+			}
+
+			url := strings.TrimSpace(repo.URL)
+			if url == "" {
+				problems = append(problems, fmt.Sprintf("%s is missing a URL", location))
+				continue
+			}
+			pieces, err := parseHostedGit(url)
+			if err != nil || pieces.Org == "" || pieces.RepoName == "" {
+				problems = append(problems, fmt.Sprintf("%s URL '%s' does not name an org and repo", location, url))
+				continue
+			}
+
+			key := learn.RepoPieces{
+				Origin:   strings.ToLower(pieces.Origin),
+				Org:      strings.ToLower(pieces.Org),
+				RepoName: strings.ToLower(pieces.RepoName),
+			}
+			if first, ok := firstSection[key]; ok {
+				problems = append(problems, fmt.Sprintf("%s URL '%s' duplicates a repo already listed in Section '%s'", location, url, first))
+				continue
+			}
+			firstSection[key] = sectionName
+		}
+	}
+
+	return problems
+}
+
+// unpublishedRepos asks Learn for the block of every repo in the course, returning a problem for each
+// repo which is not a published block
+func (c CourseYaml) unpublishedRepos(ctx context.Context) []string {
+	problems := []string{}
+	for _, url := range c.repoURLs() {
+		pieces, err := parseHostedGit(url)
+		if err != nil {
+			continue
+		}
+
+		block, err := learn.API.GetBlockByRepoName(ctx, pieces)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Could not fetch the block for '%s' from Learn: %v", url, err))
+		} else if !block.Exists() {
+			problems = append(problems, fmt.Sprintf("'%s' has not been published to Learn, run 'learn publish' from the repo", url))
+		}
+	}
+	return problems
+}
+
+// printCourseProblems writes each course.yaml problem to w
+func printCourseProblems(w io.Writer, path string, problems []string) {
+	fmt.Fprintf(w, "%s has %d problems:\n", path, len(problems))
+	for _, problem := range problems {
+		fmt.Fprintf(w, "  - %s\n", problem)
+	}
+}
+
+// containsString reports if s is one of values
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// repoURLs returns the url of every repo in the course in order, without duplicates
+func (c CourseYaml) repoURLs() []string {
+	seen := map[string]bool{}
+	urls := []string{}
+	for _, section := range c.Course {
+		for _, repo := range section.Repos {
+			url := strings.TrimSpace(repo.URL)
+			if url == "" || seen[url] {
+				continue
+			}
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// publishCourseRepos publishes each url with at most parallel releases in flight. Results are
+// returned in the same order as urls.
+func publishCourseRepos(ctx context.Context, urls []string, branch string, parallel int) []coursePublishResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]coursePublishResult, len(urls))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, url := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, url string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = publishCourseRepo(ctx, url, branch)
+			if results[i].Err != nil {
+				fmt.Printf("  x %s\n", url)
+			} else {
+				fmt.Printf("  √ %s\n", url)
+			}
+		}(i, url)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// publishCourseRepo finds or creates the block for url, then releases the branch and polls until it is built
+func publishCourseRepo(ctx context.Context, url, branch string) coursePublishResult {
+	result := coursePublishResult{URL: url}
+
+	repoPieces, err := parseHostedGit(url)
+	if err != nil {
+		result.Err = fmt.Errorf("Could not parse repo url: %v", err)
+		return result
+	}
+	if repoPieces.Org == "" || repoPieces.RepoName == "" {
+		result.Err = fmt.Errorf("Could not find an org and repo name in url")
+		return result
+	}
+	result.Repo = repoPieces
+
+	block, err := learn.API.GetBlockByRepoName(ctx, repoPieces)
+	if err != nil {
+		result.Err = fmt.Errorf("Error fetching block from learn: %w", err)
+		return result
+	}
+	if !block.Exists() {
+		block, err = learn.API.CreateBlockByRepoName(ctx, repoPieces)
+		if err != nil {
+			result.Err = fmt.Errorf("Error creating block from learn: %w", err)
+			return result
+		}
+		result.Created = true
+	}
+	result.BlockID = block.ID
+
+	releaseID, err := learn.API.CreateBranchRelease(ctx, block.ID, branch)
+	if err != nil || releaseID == 0 {
+		result.Err = fmt.Errorf("Release failed. releaseID: %d. Error: %w", releaseID, err)
+		return result
+	}
+	result.ReleaseID = releaseID
+
+	p, err := learn.API.PollForBuildResponse(ctx, releaseID, false, "")
+	if p != nil {
+		result.Warnings = p.SyncWarnings
+	}
+	if err != nil {
+		result.Err = err
+		if p != nil && p.Errors != "" {
+			result.Err = fmt.Errorf("Release failed: %s", p.Errors)
+		}
+	}
+
+	// the block holds sync errors from this and earlier releases, even when this release succeeded
+	block, err = learn.API.GetBlockByRepoName(ctx, repoPieces)
+	if err == nil {
+		result.SyncErrors = block.SyncErrors
+	}
+
+	return result
+}
+
+// printCoursePublishSummary writes the outcome of each publish to w and reports if any of them failed
+func printCoursePublishSummary(w io.Writer, results []coursePublishResult) (failed bool) {
+	var succeeded int
+	fmt.Fprintln(w, "\nSummary:")
+	for _, r := range results {
+		if r.Err != nil {
+			failed = true
+			fmt.Fprintf(w, "\nFAILED %s\n  %v\n", r.URL, r.Err)
+		} else {
+			succeeded++
+			created := ""
+			if r.Created {
+				created = " (new block)"
+			}
+			fmt.Fprintf(w, "\nOK %s%s\n  %s/blocks/%d\n", r.URL, created, learn.API.BaseURL(), r.BlockID)
+		}
+
+		if len(r.Warnings) > 0 {
+			fmt.Fprintln(w, "  Warnings:")
+			for _, warning := range r.Warnings {
+				fmt.Fprintf(w, "    %s\n", warning)
+			}
+		}
+		if len(r.SyncErrors) > 0 {
+			fmt.Fprintln(w, "  Errors on block:")
+			for _, e := range r.SyncErrors {
+				fmt.Fprintf(w, "    %s\n", e)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d of %d repos published\n", succeeded, len(results))
+
+	return failed
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gSchool/glearn-cli/api"
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/gSchool/glearn-cli/api/learn/learntest"
+	"github.com/spf13/viper"
+)
+
+const courseFixture = "../../fixtures/test-course/course.yaml"
+
+const credentialsResponseBody = `{"presigned_url":"https://aws-presigned-url.com","dev_notify_url":"development","user_id":5,"user_email":"abc@example.com"}`
+
+func Test_readCourseYaml(t *testing.T) {
+	course, err := readCourseYaml(courseFixture)
+	if err != nil {
+		t.Fatalf("readCourseYaml errored: %s\n", err)
+	}
+	if course.DefaultUnitVisibility != "hidden" {
+		t.Errorf("DefaultUnitVisibility should be 'hidden' but was '%s'", course.DefaultUnitVisibility)
+	}
+	if len(course.Course) != 2 || course.Course[0].Section != "Fundamentals" {
+		t.Errorf("course should have two sections starting with Fundamentals, had %+v", course.Course)
+	}
+	if course.Course[0].Repos[1].DefaultUpdates != "manual" {
+		t.Errorf("second repo should receive manual updates, was '%s'", course.Course[0].Repos[1].DefaultUpdates)
+	}
+
+	urls := course.repoURLs()
+	expected := []string{
+		"https://github.com/gSchool/fundamentals-one.git",
+		"git@github.com:gSchool/fundamentals-two.git",
+		"https://github.com/gSchool/projects.git",
+	}
+	if strings.Join(urls, ",") != strings.Join(expected, ",") {
+		t.Errorf("repoURLs should be %v in order without duplicates, was %v", expected, urls)
+	}
+
+	if _, err = readCourseYaml("../../fixtures/test-course/missing.yaml"); err == nil {
+		t.Errorf("readCourseYaml should error for a missing file")
+	}
+}
+
+func Test_validateCourseYaml(t *testing.T) {
+	data, _ := ioutil.ReadFile(courseFixture)
+	course, problems := parseCourseYamlStrict(data)
+	problems = append(problems, course.validate()...)
+	if len(problems) != 1 || !strings.Contains(problems[0], "fundamentals-one.git' duplicates a repo already listed in Section 'Fundamentals'") {
+		t.Errorf("course fixture should only have its duplicated repo reported, had problems: %v", problems)
+	}
+
+	data, _ = ioutil.ReadFile("../../fixtures/test-course/invalid-course.yaml")
+	course, problems = parseCourseYamlStrict(data)
+	problems = append(problems, course.validate()...)
+
+	expected := []string{
+		"field Url not found",
+		"DefaultUnitVisibility 'visible' is not allowed",
+		"DefaultUpdates 'sometimes' is not allowed",
+		"URL 'not-a-repo' does not name an org and repo",
+		"Course[1] is missing a Section name",
+		"duplicates a repo already listed in Section 'Fundamentals'",
+		"Repos[1] is missing a URL",
+		"Section 'Empty' has no Repos",
+	}
+	all := strings.Join(problems, "\n")
+	for _, e := range expected {
+		if !strings.Contains(all, e) {
+			t.Errorf("problems should include '%s', were:\n%s", e, all)
+		}
+	}
+	if len(problems) != len(expected) {
+		t.Errorf("expected %d problems but found %d:\n%s", len(expected), len(problems), all)
+	}
+}
+
+func Test_unpublishedRepos(t *testing.T) {
+	viper.Set("api_token", "apiToken")
+	mockClient := api.MockResponses(
+		credentialsResponseBody,
+		`{"blocks":[{"id":7,"repo_name":"fundamentals-one"}]}`,
+		`{"blocks":[]}`,
+		`{"blocks":[{"id":9,"repo_name":"projects"}]}`,
+	)
+	learn.API, _ = learn.NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+
+	course, _ := readCourseYaml(courseFixture)
+	problems := course.unpublishedRepos(context.Background())
+	if len(problems) != 1 || !strings.Contains(problems[0], "fundamentals-two.git' has not been published") {
+		t.Errorf("only fundamentals-two should be reported as unpublished, problems: %v", problems)
+	}
+}
+
+func Test_publishCourseRepos(t *testing.T) {
+	viper.Set("api_token", "apiToken")
+	mockClient := api.MockResponses(
+		credentialsResponseBody,
+		`{"blocks":[{"id":7,"repo_name":"projects"}]}`,
+		`{"release_id":11}`,
+		`{"status":"success","release_id":11,"sync_warnings":["unit has no lessons"]}`,
+		`{"blocks":[{"id":7,"repo_name":"projects","sync_errors":["broken link in README.md"]}]}`,
+	)
+	learn.API, _ = learn.NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+
+	var results []coursePublishResult
+	captureStdout(func() {
+		results = publishCourseRepos(context.Background(), []string{"https://github.com/gSchool/projects.git"}, "main", 1)
+	})
+	if len(results) != 1 {
+		t.Fatalf("one result should be returned for each url, got %d", len(results))
+	}
+
+	r := results[0]
+	if r.Err != nil {
+		t.Errorf("publishing should have succeeded but errored: %s", r.Err)
+	}
+	if r.BlockID != 7 || r.ReleaseID != 11 || r.Created {
+		t.Errorf("result should be for existing block 7 and release 11, was %+v", r)
+	}
+	if len(r.Warnings) != 1 {
+		t.Errorf("sync warnings should be collected from the release, was %v", r.Warnings)
+	}
+	if len(r.SyncErrors) != 1 {
+		t.Errorf("block sync errors should be collected after a successful release, was %v", r.SyncErrors)
+	}
+
+	release := mockClient.Requests[2]
+	if release.URL.String() != "https://example.com/api/v1/blocks/7/releases?branch_name=main" {
+		t.Errorf("release should be created for branch main on block 7, was %s", release.URL.String())
+	}
+
+	var out bytes.Buffer
+	results = append(results, coursePublishResult{URL: "bad-url", Err: errors.New("Could not find an org and repo name in url")})
+	failed := printCoursePublishSummary(&out, results)
+	if !failed {
+		t.Errorf("summary should report a failure when any repo failed")
+	}
+	if !strings.Contains(out.String(), "1 of 2 repos published") || !strings.Contains(out.String(), "unit has no lessons") || !strings.Contains(out.String(), "broken link in README.md") {
+		t.Errorf("summary should count successes and list warnings and block errors, was:\n%s", out.String())
+	}
+}
+
+func Test_publishCourseRepos_fakeLearn(t *testing.T) {
+	s := learntest.NewServer()
+	defer s.Close()
+	s.BuildPolls = 2
+	existing := s.AddBlock(learn.Block{Origin: "github.com", Org: "gSchool", RepoName: "fundamentals-one"})
+
+	viper.Set("api_token", learntest.Token)
+	learn.API, _ = learn.NewAPI(context.Background(), s.URL, learntest.Token, s.Client(), false)
+	learn.API.PollInterval = time.Millisecond
+
+	urls := []string{
+		"https://github.com/gSchool/fundamentals-one.git",
+		"https://github.com/gSchool/fundamentals-two.git",
+		"git@github.com:gSchool/projects.git",
+	}
+	var results []coursePublishResult
+	captureStdout(func() {
+		results = publishCourseRepos(context.Background(), urls, "master", 3)
+	})
+
+	for i, r := range results {
+		if r.Err != nil || r.URL != urls[i] || len(s.Releases(r.BlockID)) != 1 {
+			t.Errorf("%s should be released once, got %+v", urls[i], r)
+		}
+		if r.Created == (r.BlockID == existing.ID) {
+			t.Errorf("only new blocks should be reported as created, got %+v", r)
+		}
+	}
+	if len(s.Blocks()) != 3 {
+		t.Errorf("two blocks should be created, got %+v", s.Blocks())
+	}
+
+	s.FailNext(learntest.RouteBlockReleases, 500)
+	captureStdout(func() {
+		results = publishCourseRepos(context.Background(), urls[:1], "master", 1)
+	})
+	var apiErr *learn.APIError
+	if !errors.As(results[0].Err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Errorf("a failed release should be reported with its APIError, got %v", results[0].Err)
+	}
+}
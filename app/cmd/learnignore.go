@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	di "github.com/gSchool/glearn-cli/ignorematcher"
+)
+
+// learnIgnoreFile is the name of the files listing what to leave out of previews and autoconfigs
+const learnIgnoreFile = ".learnignore"
+
+// learnIgnore is the .learnignore files of a block, at its root and in any of its directories.
+// Patterns follow .gitignore, relative to the directory of the file they are in.
+type learnIgnore struct {
+	// matchers are keyed by the slash separated directory of their file relative to the root, "." for the root
+	matchers map[string]*di.Matcher
+}
+
+// loadLearnIgnore reads every .learnignore in the block at root. A block without any ignores nothing.
+func loadLearnIgnore(root string) (*learnIgnore, error) {
+	l := &learnIgnore{matchers: map[string]*di.Matcher{}}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || info.Name() != learnIgnoreFile {
+			return nil
+		}
+
+		source, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		m, err := di.Parse(di.Git, filepath.ToSlash(source), f)
+		if err != nil {
+			return err
+		}
+		l.matchers[path.Dir(filepath.ToSlash(source))] = m
+		return nil
+	})
+	return l, err
+}
+
+// ignored reports if rel, a path relative to the root of the block, is left out
+func (l *learnIgnore) ignored(rel string, isDir bool) bool {
+	ignored, _ := l.match(rel, isDir)
+	return ignored
+}
+
+// match reports if rel, a path relative to the root of the block, is left out along with the rule
+// deciding it. Anything in an ignored directory is ignored, otherwise the .learnignore closest to
+// the path decides, as with .gitignore files.
+func (l *learnIgnore) match(rel string, isDir bool) (bool, *di.Rule) {
+	if l == nil || len(l.matchers) == 0 {
+		return false, nil
+	}
+
+	rel = path.Clean(filepath.ToSlash(rel))
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if ignored, rule := l.matchOwn(strings.Join(parts[:i], "/"), true); ignored {
+			return true, rule
+		}
+	}
+	return l.matchOwn(rel, isDir)
+}
+
+// matchOwn checks rel against each .learnignore in the directories above it, the deepest deciding
+func (l *learnIgnore) matchOwn(rel string, isDir bool) (ignored bool, rule *di.Rule) {
+	dirs := []string{"."}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		dirs = append(dirs, strings.Join(parts[:i], "/"))
+	}
+
+	for _, dir := range dirs {
+		m, ok := l.matchers[dir]
+		if !ok {
+			continue
+		}
+		sub := rel
+		if dir != "." {
+			sub = strings.TrimPrefix(rel, dir+"/")
+		}
+		if matched, r := m.Match(sub, isDir); r != nil {
+			ignored, rule = matched, r
+		}
+	}
+	return ignored, rule
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeBlock creates the files of a block in a temp directory, keyed by their slash separated path
+func writeBlock(t *testing.T, files map[string]string) string {
+	block := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(block, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return block
+}
+
+func Test_learnIgnore(t *testing.T) {
+	block := writeBlock(t, map[string]string{
+		".learnignore":                "# drafts\ndrafts/\n*.csv\n!small.csv\n",
+		"units/.learnignore":          "notes.md\n!keep.csv\n",
+		"units/intro.md":              "# Intro",
+		"units/notes.md":              "# Notes",
+		"units/keep.csv":              "a,b",
+		"notes.md":                    "# Notes",
+		"drafts/lesson.md":            "# Draft",
+		"node_modules/a/.learnignore": "*",
+	})
+	ignore, err := loadLearnIgnore(block)
+	if err != nil {
+		t.Fatalf("loadLearnIgnore errored: %v", err)
+	}
+	if len(ignore.matchers) != 2 {
+		t.Errorf("loadLearnIgnore should read the .learnignore files outside node_modules, got %d", len(ignore.matchers))
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+		rule    string
+	}{
+		{"units/intro.md", false, false, ""},
+		{"drafts", true, true, ".learnignore:2: drafts/"},
+		{"drafts/lesson.md", false, true, ".learnignore:2: drafts/"},
+		{"data/big.csv", false, true, ".learnignore:3: *.csv"},
+		{"data/small.csv", false, false, ".learnignore:4: !small.csv"},
+		{"units/notes.md", false, true, "units/.learnignore:1: notes.md"},
+		{"notes.md", false, false, ""},
+		{"units/keep.csv", false, false, "units/.learnignore:2: !keep.csv"},
+	}
+	for _, test := range tests {
+		ignored, rule := ignore.match(filepath.FromSlash(test.path), test.isDir)
+		if ignored != test.ignored {
+			t.Errorf("%s should be ignored: %v, got %v", test.path, test.ignored, ignored)
+		}
+		if (rule == nil && test.rule != "") || (rule != nil && rule.String() != test.rule) {
+			t.Errorf("%s should be decided by %q, got %v", test.path, test.rule, rule)
+		}
+	}
+
+	var none *learnIgnore
+	if none.ignored("units/intro.md", false) {
+		t.Errorf("a block without .learnignore files should ignore nothing")
+	}
+}
+
+func Test_learnIgnoreAutoConfig(t *testing.T) {
+	block := writeBlock(t, map[string]string{
+		".learnignore":           "drafts/\n__wip.md\n",
+		"units/intro.md":         "# Intro",
+		"units/__wip.md":         "# Work in progress",
+		"units/drafts/later.md":  "# Later",
+		"units/01-sql/select.md": "# Select",
+	})
+
+	autoConfig, err := previewFindOrCreateConfig(block+"/", false, []string{})
+	if err != nil {
+		t.Fatalf("previewFindOrCreateConfig errored: %v", err)
+	}
+	config := string(autoConfig)
+	if !strings.Contains(config, "/units/intro.md") || !strings.Contains(config, "/units/01-sql/select.md") {
+		t.Errorf("the autoconfig should have the lessons which are not ignored, got:\n%s", config)
+	}
+	if strings.Contains(config, "later.md") || strings.Contains(config, "__wip.md") {
+		t.Errorf("the autoconfig should leave out what .learnignore matches, got:\n%s", config)
+	}
+}
+
+func Test_dryRun(t *testing.T) {
+	block := writeBlock(t, map[string]string{
+		".learnignore":       "solutions/\n",
+		"config.yaml":        "Standards: []",
+		"units/intro.md":     "# Intro",
+		"solutions/intro.md": "# Answers",
+		"scratch.txt":        "notes",
+	})
+	ignore, err := loadLearnIgnore(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &previewBuilder{
+		target:          block,
+		ignore:          ignore,
+		configYamlPaths: []string{"/units/intro.md"},
+	}
+
+	var out bytes.Buffer
+	if err := p.dryRun(&out); err != nil {
+		t.Fatalf("dryRun errored: %v", err)
+	}
+	for _, line := range []string{
+		"  config.yaml\n",
+		"  units/intro.md\n",
+		"  solutions/    ignored by .learnignore:1: solutions/\n",
+		"  scratch.txt   not a lesson, or linked from one\n",
+		"nothing was uploaded",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("dryRun should print %q, got:\n%s", line, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "solutions/intro.md") {
+		t.Errorf("dryRun should not list the contents of an ignored directory, got:\n%s", out.String())
+	}
+}
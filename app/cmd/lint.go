@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gSchool/glearn-cli/git"
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [directory]",
+	Short: "Check a block for files which would fail a release",
+	Long: `
+Checks the files committed in a block, the current directory by default, against
+its size limits. Files over the publish limit fail the release, so lint exits
+with an error when it finds any, and can be run in CI before publishing. Files
+over the preview limit are listed, as they are left out of previews. Files in
+.learnignore are listed separately: they are left out of previews, but releases
+are built from the repository, so they still fail a release when they are over
+the publish limit. The preview limit can be changed in the .learn.yaml at the
+root of the block, and the publish limit lowered below Learn's 20MB:
+
+  SizeLimits:
+    Preview: 2MB
+    Publish: 10MB
+	`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		blockDir := "."
+		if len(args) == 1 {
+			blockDir = args[0]
+		}
+
+		limits, err := loadSizeLimits(blockDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		ignore, err := loadLearnIgnore(blockDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not read the %s files in %s. Err: %v\n", learnIgnoreFile, blockDir, err)
+			os.Exit(1)
+		}
+		// the block may be in another repository than the current directory
+		oversized, ignored, err := lintFileSizes(git.NewNative(blockDir), blockDir, limits, ignore)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not list the committed files in %s. Err: %v\n", blockDir, err)
+			os.Exit(1)
+		}
+		if printLintResult(os.Stdout, blockDir, oversized, ignored, limits) {
+			os.Exit(1)
+		}
+	},
+}
+
+// lintFileSizes finds the files committed to repo in the block which are over its size limits. Files
+// in .learnignore are returned apart from the others, as Learn still builds releases with them.
+func lintFileSizes(repo git.Repo, blockDir string, limits *sizeLimits, ignore *learnIgnore) (oversized, ignored []oversizedFile, err error) {
+	tracked, err := repo.TrackedFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := filesInBlock(repo, blockDir, tracked)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	oversized, ignored = []oversizedFile{}, []oversizedFile{}
+	for _, path := range files {
+		// a committed file deleted from the working tree is not checked
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		rel, err := relPath(blockDir, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		if f, over := limits.check(rel, info.Size()); over {
+			if ignore.ignored(rel, false) {
+				ignored = append(ignored, f)
+			} else {
+				oversized = append(oversized, f)
+			}
+		}
+	}
+	return oversized, ignored, nil
+}
+
+// printLintResult writes the files over the size limits to w, with those in .learnignore listed
+// apart, and reports if any fail a release
+func printLintResult(w io.Writer, blockDir string, oversized, ignored []oversizedFile, limits *sizeLimits) (failed bool) {
+	if len(oversized) == 0 && len(ignored) == 0 {
+		fmt.Fprintf(w, "No problems found in %s\n", blockDir)
+		return false
+	}
+
+	if len(oversized) > 0 {
+		printOversizedFiles(w, oversized, limits)
+	}
+	if len(ignored) > 0 {
+		if len(oversized) > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "In %s, left out of previews but still released:\n", learnIgnoreFile)
+		printOversizedFiles(w, ignored, limits)
+	}
+
+	for i, f := range append(append([]oversizedFile{}, oversized...), ignored...) {
+		if !f.FailsPublish {
+			continue
+		}
+		note := ""
+		if i >= len(oversized) {
+			note = fmt.Sprintf(", even when they are in %s", learnIgnoreFile)
+		}
+		fmt.Fprintf(w, "\nERROR: Files over the publish limit of %s will fail the release%s. Remove them from the repository before publishing.\n", formatBytes(limits.publish()), note)
+		return true
+	}
+	if len(oversized) > 0 {
+		fmt.Fprintf(w, "\nWARNING: Files over the preview limit of %s are left out of previews.\n", formatBytes(limits.preview()))
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gSchool/glearn-cli/git"
+)
+
+func Test_lintFileSizes(t *testing.T) {
+	block := writeBlock(t, map[string]string{
+		"units/intro.md":    "# Intro",
+		"units/diagram.png": strings.Repeat("a", 2000),
+		"data/big.csv":      strings.Repeat("a", 6000),
+		"scratch.csv":       strings.Repeat("a", 6000),
+		".learnignore":      "data/\n",
+	})
+	repo := &git.Fake{TopLevel: filepath.Dir(block), Tracked: []string{
+		filepath.Base(block) + "/units/intro.md",
+		filepath.Base(block) + "/units/diagram.png",
+		filepath.Base(block) + "/data/big.csv",
+		filepath.Base(block) + "/units/deleted.md",
+		"other-block/huge.zip",
+	}}
+
+	ignore, err := loadLearnIgnore(block)
+	if err != nil {
+		t.Fatalf("loadLearnIgnore errored: %v", err)
+	}
+	oversized, ignored, err := lintFileSizes(repo, block, &sizeLimits{Preview: 1000, Publish: 5000}, ignore)
+	if err != nil {
+		t.Fatalf("lintFileSizes errored: %v", err)
+	}
+	if expected := []oversizedFile{{"units/diagram.png", 2000, false}}; !reflect.DeepEqual(oversized, expected) {
+		t.Errorf("lintFileSizes should find the committed files over the limits, got %+v", oversized)
+	}
+	if expected := []oversizedFile{{"data/big.csv", 6000, true}}; !reflect.DeepEqual(ignored, expected) {
+		t.Errorf("lintFileSizes should find the ignored files over the limits apart from the others, got %+v", ignored)
+	}
+
+	repo = &git.Fake{Errs: map[string]error{"TrackedFiles": os.ErrNotExist}}
+	if _, _, err := lintFileSizes(repo, block, nil, nil); err == nil {
+		t.Errorf("lintFileSizes should return the error from git")
+	}
+}
+
+func Test_lintFileSizes_otherRepository(t *testing.T) {
+	block := writeBlock(t, map[string]string{
+		"units/intro.md": "# Intro",
+		"data/big.csv":   strings.Repeat("a", 6000),
+	})
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"-c", "user.name=learn", "-c", "user.email=learn@example.com", "commit", "-q", "-m", "block"}} {
+		if out, err := exec.Command("git", append([]string{"-C", block}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	oversized, _, err := lintFileSizes(git.NewNative(block), block, &sizeLimits{Preview: 1000, Publish: 5000}, nil)
+	if err != nil {
+		t.Fatalf("lintFileSizes errored: %v", err)
+	}
+	if expected := []oversizedFile{{"data/big.csv", 6000, true}}; !reflect.DeepEqual(oversized, expected) {
+		t.Errorf("lintFileSizes should check the files committed in the repository of the block, got %+v", oversized)
+	}
+}
+
+func Test_printLintResult(t *testing.T) {
+	var out bytes.Buffer
+	if printLintResult(&out, ".", []oversizedFile{}, []oversizedFile{}, nil) || !strings.Contains(out.String(), "No problems found") {
+		t.Errorf("printLintResult should pass without oversized files, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if printLintResult(&out, ".", []oversizedFile{{"video.mp4", 5000000, false}}, nil, nil) || !strings.Contains(out.String(), "WARNING: Files over the preview limit of 1.0 MB") {
+		t.Errorf("printLintResult should only warn about files too large to preview, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if !printLintResult(&out, ".", []oversizedFile{{"video.mp4", 5000000, false}, {"data.zip", 25000000, true}}, nil, nil) || !strings.Contains(out.String(), "ERROR: Files over the publish limit of 20.0 MB will fail the release.") {
+		t.Errorf("printLintResult should fail with files too large to publish, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if printLintResult(&out, ".", nil, []oversizedFile{{"data/big.csv", 5000000, false}}, nil) || strings.Contains(out.String(), "WARNING") || !strings.Contains(out.String(), "data/big.csv") {
+		t.Errorf("printLintResult should list ignored files apart without a preview warning, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if !printLintResult(&out, ".", nil, []oversizedFile{{"data/big.zip", 25000000, true}}, nil) || !strings.Contains(out.String(), "even when they are in .learnignore") {
+		t.Errorf("printLintResult should fail with ignored files too large to publish, got:\n%s", out.String())
+	}
+}
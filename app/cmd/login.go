@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// apiTokenEnv is the environment variable overriding the API token of the profile, e.g. in CI
+const apiTokenEnv = "LEARN_API_TOKEN"
+
+// configPath is the location of ~/.glearn-config.yaml, set when the config is read
+var configPath string
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to Learn with your API token",
+	Long: `
+Log in to Learn by pasting your API token at the prompt, or by piping it in:
+
+  learn login
+  echo "$TOKEN" | learn login
+
+The token is checked with Learn before it is saved to ~/.glearn-config.yaml,
+and unlike learn set --api_token it is not left in your shell history. Use
+--profile to log in to another Learn environment.
+	`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profile, _ := findProfile(profileName())
+		profile.BaseURL = stringOr(BaseURL, stringOr(profile.BaseURL, defaultBaseURL))
+		if alternateURL := os.Getenv("LEARN_BASE_URL"); alternateURL != "" {
+			profile.BaseURL = alternateURL
+		}
+
+		token, err := readToken(os.Stdin, os.Stderr, fmt.Sprintf("Paste your API token from %s/api_token: ", profile.BaseURL))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		email, err := login(cmd.Context(), profile, token)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Logged in to %s as %s\n", profile, email)
+		if os.Getenv(apiTokenEnv) != "" {
+			fmt.Fprintf(os.Stderr, "Note: %s is set and will be used instead of this token\n", apiTokenEnv)
+		}
+	},
+}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the Learn user your API token belongs to",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profile, err := currentProfile()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		email, err := validateToken(cmd.Context(), profile.BaseURL, profile.APIToken)
+		if err != nil {
+			learnProfile = profile
+			fmt.Fprintln(os.Stderr, learnError(err))
+			os.Exit(1)
+		}
+
+		source := "~/.glearn-config.yaml"
+		if os.Getenv(apiTokenEnv) != "" {
+			source = apiTokenEnv
+		}
+		fmt.Printf("Logged in to %s as %s\nToken %s from %s\n", profile, email, maskToken(profile.APIToken), source)
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove your API token from ~/.glearn-config.yaml",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := profileName()
+		if _, ok := findProfile(name); !ok {
+			fmt.Fprintf(os.Stderr, "No profile named '%s' in ~/.glearn-config.yaml\n", name)
+			os.Exit(1)
+		}
+
+		if err := logout(name); err != nil {
+			fmt.Fprintf(os.Stderr, "There was an error removing the API token from your config: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Logged out of profile '%s'\n", name)
+		if os.Getenv(apiTokenEnv) != "" {
+			fmt.Fprintf(os.Stderr, "Note: %s is still set and will be used until it is unset\n", apiTokenEnv)
+		}
+	},
+}
+
+// readToken reads an API token from in. A terminal is prompted without echoing the token, otherwise
+// the first line of in is the token.
+func readToken(in *os.File, prompt io.Writer, message string) (string, error) {
+	var token string
+	if term.IsTerminal(int(in.Fd())) {
+		fmt.Fprint(prompt, message)
+		b, err := term.ReadPassword(int(in.Fd()))
+		fmt.Fprintln(prompt)
+		if err != nil {
+			return "", fmt.Errorf("Could not read your API token: %v", err)
+		}
+		token = string(b)
+	} else {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("Could not read your API token: %v", err)
+		}
+		token = line
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.New("No API token was given")
+	}
+	return token, nil
+}
+
+// login checks the token with Learn and saves it to the profile, returning the email of the user
+func login(ctx context.Context, profile Profile, token string) (string, error) {
+	email, err := validateToken(ctx, profile.BaseURL, token)
+	if err != nil {
+		var apiErr *learn.APIError
+		if errors.As(err, &apiErr) && apiErr.Unauthorized() {
+			return "", fmt.Errorf("Learn did not accept that API token, nothing was saved. You can get your api token at %s/api_token", profile.BaseURL)
+		}
+		learnProfile = profile
+		return "", errors.New(learnError(err))
+	}
+
+	// Only a --base_url flag changes the base URL of the profile, not LEARN_BASE_URL
+	setProfile(profile.Name, token, BaseURL)
+
+	if err := writeConfig(); err != nil {
+		return "", fmt.Errorf("There was an error writing credentials to your config: %v", err)
+	}
+	return email, nil
+}
+
+// offerLogin asks to log in to the profile again after Learn rejected its API token, running the
+// login flow when the answer is yes. What the user can do next is returned.
+func offerLogin(in *os.File, prompt io.Writer, profile Profile) string {
+	fmt.Fprintf(prompt, "Learn did not accept the API token of %s. Log in again now? [y/N] ", profile)
+	answer, err := readAnswer(in)
+	if err != nil || (answer != "y" && answer != "yes") {
+		return "Learn did not accept your API token." + setTokenMessage(profile)
+	}
+
+	token, err := readToken(in, prompt, fmt.Sprintf("Paste your API token from %s/api_token: ", profile.BaseURL))
+	if err != nil {
+		return err.Error()
+	}
+	email, err := login(context.Background(), profile, token)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("Logged in to %s as %s, run the command again to use the new token.", profile, email)
+}
+
+// readAnswer reads a line from in one byte at a time, so nothing after the line is consumed, and
+// returns it trimmed and lowercased
+func readAnswer(in io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := in.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(string(line))), nil
+}
+
+// logout removes the token of the named profile from the config
+func logout(name string) error {
+	key := "api_token"
+	if name != defaultProfileName {
+		key = "profiles." + name + ".api_token"
+	}
+	viper.Set(key, "")
+	return writeConfig()
+}
+
+// validateToken retrieves the CLI's credentials from Learn with token, returning the user's email
+func validateToken(ctx context.Context, baseURL, token string) (string, error) {
+	client, err := newHTTPClient()
+	if err != nil {
+		return "", err
+	}
+	if _, err := learn.NewAPI(ctx, baseURL, token, client, false); err != nil {
+		return "", err
+	}
+	return learn.LearnUserEmail, nil
+}
+
+// writeConfig writes the config to ~/.glearn-config.yaml, readable only by the user since it holds tokens
+func writeConfig() error {
+	path := configPath
+	if path == "" {
+		path = viper.ConfigFileUsed()
+	}
+	if err := viper.WriteConfigAs(path); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gSchool/glearn-cli/api/learn/learntest"
+	"github.com/spf13/viper"
+)
+
+// tempConfig points writeConfig at a config file in a temporary directory
+func tempConfig(t *testing.T) string {
+	resetProfiles(t)
+	path := filepath.Join(t.TempDir(), ".glearn-config.yaml")
+	previous := configPath
+	configPath = path
+	t.Cleanup(func() { configPath = previous })
+	return path
+}
+
+func Test_login(t *testing.T) {
+	path := tempConfig(t)
+	s := learntest.NewServer()
+	defer s.Close()
+
+	profile := Profile{Name: "local", BaseURL: s.URL}
+	if _, err := login(context.Background(), profile, "not-a-token"); err == nil {
+		t.Errorf("login should fail when Learn does not accept the token")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("login should not write the config for a rejected token")
+	}
+
+	email, err := login(context.Background(), profile, learntest.Token)
+	if err != nil || email != s.UserEmail {
+		t.Errorf("login should return the email of the user, got %s and %v", email, err)
+	}
+	if viper.GetString("profiles.local.api_token") != learntest.Token {
+		t.Errorf("login should save the token to the profile")
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("login should write the config readable only by the user, got %v and %v", info, err)
+	}
+
+	if err := logout("local"); err != nil {
+		t.Errorf("logout should succeed, got %v", err)
+	}
+	ProfileName = "local"
+	if _, err := currentProfile(); err == nil {
+		t.Errorf("the profile should have no token after logout")
+	}
+}
+
+func Test_currentProfile_APITokenEnv(t *testing.T) {
+	tempConfig(t)
+	viper.Set("api_token", "config-token")
+
+	t.Setenv(apiTokenEnv, "env-token")
+	p, err := currentProfile()
+	if err != nil || p.APIToken != "env-token" {
+		t.Errorf("LEARN_API_TOKEN should override the token of the profile, got %+v and %v", p, err)
+	}
+
+	viper.Set("api_token", "")
+	if _, err := currentProfile(); err != nil {
+		t.Errorf("LEARN_API_TOKEN should be enough without a token in the config, got %v", err)
+	}
+}
+
+func Test_readToken(t *testing.T) {
+	read := func(input string) (string, error) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		w.WriteString(input)
+		w.Close()
+		return readToken(r, os.Stderr, "")
+	}
+
+	if token, err := read("  my-token \nignored\n"); err != nil || token != "my-token" {
+		t.Errorf("readToken should read the first line of a pipe, got %q and %v", token, err)
+	}
+	if _, err := read("\n"); err == nil {
+		t.Errorf("readToken should fail without a token")
+	}
+}
+
+func Test_offerLogin(t *testing.T) {
+	tempConfig(t)
+	s := learntest.NewServer()
+	defer s.Close()
+
+	offer := func(input string) string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		w.WriteString(input)
+		w.Close()
+		return offerLogin(r, ioutil.Discard, Profile{Name: "local", BaseURL: s.URL})
+	}
+
+	if msg := offer("n\n" + learntest.Token + "\n"); !strings.Contains(msg, "learn login --profile=local") {
+		t.Errorf("declining should describe how to log in later, got %q", msg)
+	}
+	if viper.GetString("profiles.local.api_token") != "" {
+		t.Errorf("declining should not save a token")
+	}
+
+	if msg := offer("Y\n" + learntest.Token + "\n"); !strings.Contains(msg, "Logged in to") || !strings.Contains(msg, s.UserEmail) {
+		t.Errorf("accepting should log in with the pasted token, got %q", msg)
+	}
+	if viper.GetString("profiles.local.api_token") != learntest.Token {
+		t.Errorf("accepting should save the token to the profile")
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/cheggaaa/pb/v3"
+	"github.com/gSchool/glearn-cli/proxy_reader"
+	"golang.org/x/term"
+)
+
+// Output is the global flag for the output format, text or json
+var Output string
+
+// resultOut is where the result of a command is written with --output json. Everything else a
+// command prints goes to stderr, so stdout holds only the result.
+var resultOut io.Writer = os.Stdout
+
+// runResult is the result of the preview or publish being run, written when it finishes
+var runResult *commandResult
+
+// commandResult is the single JSON object written by preview and publish with --output json
+type commandResult struct {
+	Command    string      `json:"command"`
+	Succeeded  bool        `json:"succeeded"`
+	PreviewURL string      `json:"preview_url,omitempty"`
+	ReleaseID  int         `json:"release_id,omitempty"`
+	BlockID    int         `json:"block_id,omitempty"`
+	BlockURL   string      `json:"block_url,omitempty"`
+	Warnings   []string    `json:"warnings,omitempty"`
+	SyncErrors []string    `json:"sync_errors,omitempty"`
+	Previews   []ciPreview `json:"previews,omitempty"`
+	Timings    *timings    `json:"timings,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// setupOutput checks the --output flag. With json, stdout is kept for the result and everything
+// else printed is sent to stderr.
+func setupOutput() error {
+	if Output != "text" && Output != "json" {
+		return fmt.Errorf("--output must be 'text' or 'json', got '%s'", Output)
+	}
+	if jsonOutput() && os.Stdout != os.Stderr {
+		resultOut = os.Stdout
+		os.Stdout = os.Stderr
+	}
+	return nil
+}
+
+// jsonOutput reports if the --output json flag was given
+func jsonOutput() bool {
+	return Output == "json"
+}
+
+// interactive reports if spinners and color can be shown, which is only for text output to a terminal
+func interactive() bool {
+	return !jsonOutput() && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// startSpinner starts a spinner when the output is interactive, otherwise it returns nil
+func startSpinner(charSet int, color string) *spinner.Spinner {
+	if !interactive() {
+		return nil
+	}
+	s := spinner.New(spinner.CharSets[charSet], 100*time.Millisecond)
+	s.Color(color)
+	s.Start()
+	return s
+}
+
+// stopSpinner stops a spinner from startSpinner, if one was started
+func stopSpinner(s *spinner.Spinner) {
+	if s != nil {
+		s.Stop()
+	}
+}
+
+// startProgressBar starts a bar of total bytes when the output is interactive, otherwise it returns nil
+func startProgressBar(total int64) *pb.ProgressBar {
+	if !interactive() {
+		return nil
+	}
+	return proxy_reader.NewBar(total, os.Stdout).Start()
+}
+
+// finishProgressBar finishes a bar from startProgressBar, if one was started
+func finishProgressBar(bar *pb.ProgressBar) {
+	if bar != nil {
+		bar.Finish()
+	}
+}
+
+// startRun begins the timings and result of a preview or publish of target
+func startRun(command, target string) {
+	runTimings = newTimings(command, target)
+	runResult = &commandResult{Command: command}
+}
+
+// finishRun finishes the timings of the command and writes its result
+func finishRun() {
+	runTimings.finish(true)
+	writeResult(resultOut, true)
+}
+
+// failRun prints msg, finishes the timings and result of the command as failed, then exits
+func failRun(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	if runResult != nil {
+		runResult.Error = msg
+	}
+	if runTimings != nil {
+		runTimings.finish(false)
+	}
+	writeResult(resultOut, false)
+	os.Exit(1)
+}
+
+// writeResult writes the result of the command to w with --output json
+func writeResult(w io.Writer, succeeded bool) {
+	if !jsonOutput() || runResult == nil {
+		return
+	}
+	runResult.Succeeded = succeeded
+	runResult.Timings = runTimings
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(runResult)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func Test_setupOutput(t *testing.T) {
+	stdout := os.Stdout
+	defer func() {
+		os.Stdout = stdout
+		resultOut = stdout
+		Output = "text"
+	}()
+
+	Output = "yaml"
+	if err := setupOutput(); err == nil {
+		t.Errorf("setupOutput should only accept text or json")
+	}
+
+	Output = "text"
+	if err := setupOutput(); err != nil || os.Stdout != stdout {
+		t.Errorf("setupOutput should leave stdout alone for text, got %v", err)
+	}
+
+	Output = "json"
+	if err := setupOutput(); err != nil || os.Stdout != os.Stderr || resultOut != stdout {
+		t.Errorf("setupOutput should keep stdout for the result and print everything else to stderr, got %v", err)
+	}
+	if interactive() || startSpinner(32, "blue") != nil {
+		t.Errorf("spinners should not be shown with json output")
+	}
+}
+
+func Test_writeResult(t *testing.T) {
+	defer func() {
+		Output = "text"
+		runResult, runTimings = nil, nil
+	}()
+
+	startRun("preview", "unit-1")
+	runTimings.begin("compress").end()
+	runResult.PreviewURL = "https://learn-2.galvanize.com/previews/1"
+	runResult.Warnings = []string{"unit has no lessons"}
+
+	var out bytes.Buffer
+	writeResult(&out, true)
+	if out.Len() != 0 {
+		t.Errorf("writeResult should write nothing for text output, got:\n%s", out.String())
+	}
+
+	Output = "json"
+	writeResult(&out, false)
+	var result commandResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("writeResult should write a single JSON object, got %v:\n%s", err, out.String())
+	}
+	if result.Command != "preview" || result.Succeeded || result.PreviewURL == "" || len(result.Warnings) != 1 {
+		t.Errorf("writeResult should include the result of the command, got %+v", result)
+	}
+	if result.Timings == nil || len(result.Timings.Phases) != 1 || result.Timings.Phases[0].Name != "compress" {
+		t.Errorf("writeResult should include the timings of the command, got %+v", result.Timings)
+	}
+}
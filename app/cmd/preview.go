@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"archive/zip"
-	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
@@ -10,15 +10,13 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
 	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/gSchool/glearn-cli/api/learn"
@@ -26,10 +24,13 @@ import (
 	"github.com/gSchool/glearn-cli/mdresourceparser"
 )
 
-// tmpSingleFileDir is used throughout as the temporary single file directory location. This
-// is the name of the tmp dir we build when needing to attach relative links.
+// tmpSingleFileDir is the name of the directory in a preview's workspace which single file and
+// lesson previews are built in, when they need their relative links attached.
 const tmpSingleFileDir string = "single-file-upload"
 
+// previewArchiveFile is the name of the preview archive in a preview's workspace
+const previewArchiveFile = "preview.zip"
+
 // previewBuilder collects information about the curriculum to be previewed
 type previewBuilder struct {
 	// target is the initial argument, which should be a file or directory
@@ -49,13 +50,39 @@ type previewBuilder struct {
 	startOfCmd time.Time
 	// bench is the benchmark metadat collected to send to Learn
 	bench *learn.CLIBenchmark
+	// compressedFiles and compressedBytes count the files added to the archive and their size
+	compressedFiles int
+	compressedBytes int64
+	// preview is Learn's response once the preview has been built
+	preview *learn.PreviewResponse
+	// lessons are the markdown files, relative to the target directory, previewed on their own
+	// instead of the whole block
+	lessons []string
+	// ignore is the .learnignore files of a directory target, what they match is left out
+	ignore *learnIgnore
+	// limits is the size policy of the block, files over its preview limit are left out
+	limits *sizeLimits
+	// oversized are the files left out of the archive for their size
+	oversized []oversizedFile
+	// archivePath is the preview archive written to the workspace, and archiveSize its length
+	archivePath string
+	archiveSize int64
+	// workspace is the temporary directory the preview's scratch files are written to, nothing is
+	// written to the block or the working directory. It is removed once the preview is done.
+	workspace string
+	// autoConfig is the autoconfig.yaml generated for a target without a config. It is added to the
+	// archive without being written next to the block's content.
+	autoConfig []byte
 }
 
-func NewPreviewBuilder(args []string) (*previewBuilder, error) {
-	setupLearnAPI(true)
+func NewPreviewBuilder(ctx context.Context, args []string) (*previewBuilder, error) {
+	// a dry run never talks to Learn, so it works without an API token
+	if !PreviewDryRun {
+		setupLearnAPI(ctx, true)
 
-	if viper.Get("api_token") == "" || viper.Get("api_token") == nil {
-		return &previewBuilder{}, fmt.Errorf(setAPITokenMessage)
+		if _, err := currentProfile(); err != nil {
+			return &previewBuilder{}, err
+		}
 	}
 
 	fileInfo, err := os.Stat(args[0])
@@ -76,9 +103,45 @@ func NewPreviewBuilder(args []string) (*previewBuilder, error) {
 		return &previewBuilder{}, fmt.Errorf("The preview file that you chose is not able to be rendered as a single file preview in learn")
 	}
 
+	if fileInfo.IsDir() {
+		p.ignore, err = loadLearnIgnore(p.target)
+		if err != nil {
+			return &previewBuilder{}, fmt.Errorf("Failed to read the %s files in %s. Err: %v", learnIgnoreFile, p.target, err)
+		}
+	}
+
+	blockRoot := p.target
+	if !fileInfo.IsDir() {
+		if blockRoot, err = findBlockRoot(filepath.Dir(p.target)); err != nil {
+			return &previewBuilder{}, err
+		}
+	}
+	if p.limits, err = loadSizeLimits(blockRoot); err != nil {
+		return &previewBuilder{}, err
+	}
+
+	if p.workspace, err = os.MkdirTemp("", "learn-preview-"); err != nil {
+		return &previewBuilder{}, fmt.Errorf("Failed to create a temporary directory for the preview. Err: %v", err)
+	}
+
 	return p, nil
 }
 
+// singleFileDir is the directory in the workspace which single file and lesson previews are built in
+func (p *previewBuilder) singleFileDir() string {
+	return filepath.Join(p.workspace, tmpSingleFileDir)
+}
+
+// removeWorkspace removes the preview's scratch files, so nothing is left on the user's machine
+func (p *previewBuilder) removeWorkspace() {
+	if p.workspace == "" {
+		return
+	}
+	if err := os.RemoveAll(p.workspace); err != nil {
+		fmt.Fprintln(os.Stderr, "Sorry, we had trouble cleaning up the preview's temporary directory", p.workspace)
+	}
+}
+
 // collectPaths reads from a file and collects required docker paths, file link paths, and other resources needed for preview
 func (p *previewBuilder) collectPaths() error {
 	// collect nothing if we do not include links
@@ -99,7 +162,21 @@ func (p *previewBuilder) collectPaths() error {
 }
 
 func (p *previewBuilder) buildAlternateTarget() error {
-	alternateTarget, err := createNewTarget(p.target, p.challengePaths, p.linkPaths, p.dockerPaths)
+	if len(p.lessons) > 0 {
+		dockerPaths, challengePaths, linkPaths, err := createLessonsTarget(p.singleFileDir(), p.target, p.lessons)
+		if err != nil {
+			return err
+		}
+		fileInfo, err := os.Stat(p.singleFileDir())
+		if err != nil {
+			return err
+		}
+		p.target, p.fileInfo = p.singleFileDir(), fileInfo
+		p.dockerPaths, p.challengePaths, p.linkPaths = dockerPaths, challengePaths, linkPaths
+		return nil
+	}
+
+	alternateTarget, err := createNewTarget(p.singleFileDir(), p.target, p.challengePaths, p.linkPaths, p.dockerPaths)
 	if err != nil {
 		return err
 	}
@@ -111,14 +188,19 @@ func (p *previewBuilder) buildAlternateTarget() error {
 	return nil
 }
 
-// setConfigYaml finds or creates a config.yaml file for the preview environment. The paths on the file are read and set.
+// setConfigYaml finds the config.yaml of the preview, or generates an autoconfig for it. The paths on
+// the config are read and set.
 func (p *previewBuilder) setConfigYaml() error {
-	_, err := previewFindOrCreateConfig(p.target, p.isSingleFilePreview(), p.dockerPaths)
-	if err != nil {
-		return fmt.Errorf("Failed to find or create a config file for: (%s).\nErr: %v", p.target, err)
+	// the config of a preview of lessons is created along with its target
+	if len(p.lessons) == 0 {
+		var err error
+		p.autoConfig, err = previewFindOrCreateConfig(p.target, p.isSingleFilePreview(), p.dockerPaths)
+		if err != nil {
+			return fmt.Errorf("Failed to find or create a config file for: (%s).\nErr: %v", p.target, err)
+		}
 	}
 
-	err = p.parseConfigAndGatherPaths()
+	err := p.parseConfigAndGatherPaths()
 	if err != nil {
 		return fmt.Errorf("Failed to parse config/autoconfig yaml for: (%s).\nErr: %v", p.target, err)
 	}
@@ -126,37 +208,29 @@ func (p *previewBuilder) setConfigYaml() error {
 	return nil
 }
 
-// compressDirectory takes a source file path (where the content you want zipped lives)
-// and a target file path (where to put the zip file) and recursively compresses the source.
-// Source can either be a directory or a single file. When singleFile is true, all files in
-// the zip are added. resourcePaths specify non-config paths which should be included in the zip.
-func (p *previewBuilder) compressDirectory(zipTarget string) error {
+// compressDirectory collects the files of the target to include in the preview and compresses
+// them concurrently into an archive in the workspace, ready to be sent to Learn by uploadZip.
+// Source can either be a directory or a single file. Compression stops early when ctx is cancelled.
+func (p *previewBuilder) compressDirectory(ctx context.Context) error {
+	level, err := compressionLevel(stringOr(PreviewCompression, "fast"))
+	if err != nil {
+		return err
+	}
+
 	// Start a processing spinner that runs until a user's content is compressed
 	fmt.Println("Compressing your content...")
-	zipSpinner := spinner.New(spinner.CharSets[26], 100*time.Millisecond)
-	zipSpinner.Color("blue")
-	zipSpinner.Start()
+	zipSpinner := startSpinner(26, "blue")
+	defer stopSpinner(zipSpinner)
 
 	// Start benchmark for compressDirectory
 	startOfCompression := time.Now()
 
 	resourcePaths := append(append(p.dockerPaths, p.challengePaths...), p.linkPaths...)
-	// Create file with zipTarget name and defer its closing
-	zipfile, err := os.Create(zipTarget)
-	if err != nil {
-		return err
-	}
-	defer zipfile.Close()
-
-	// Create a new zip writer and pass our zipfile in
-	archive := zip.NewWriter(zipfile)
-	defer archive.Close()
 
 	// Get os.FileInfo about our source
 	info, err := os.Stat(p.target)
 	if err != nil {
-		return nil
-		// why return nil and not err here?
+		return err
 	}
 
 	// Check to see if the provided source file is a directory and set baseDir if so
@@ -165,126 +239,224 @@ func (p *previewBuilder) compressDirectory(zipTarget string) error {
 		baseDir = filepath.Base(p.target)
 	}
 
-	// Walk the whole filepath
-	filepath.Walk(p.target, func(path string, info os.FileInfo, err error) error {
+	// Walk the whole filepath, collecting the entries of the archive in order
+	entries := []*archiveEntry{}
+	err = filepath.Walk(p.target, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		path = filepath.ToSlash(path)
 
-		fileIsIncluded := false
-		for _, p := range p.configYamlPaths {
-			var configPathSplits = strings.Split(p, string(os.PathSeparator))
-			var fileName = configPathSplits[len(configPathSplits)-1]
-			if strings.Contains(path, fileName) {
-				fileIsIncluded = true
-			}
+		reason := p.archiveExclusion(path, info, resourcePaths)
+		if strings.HasPrefix(reason, ignoredByPrefix) && info.IsDir() {
+			return filepath.SkipDir
 		}
-		for _, d := range resourcePaths {
-			if strings.Contains(path, d) || strings.Contains(path, trimFirstRune(d)) {
-				fileIsIncluded = true
+		// Files over the size limits are left out, and listed once the spinner has stopped
+		if strings.HasPrefix(reason, tooLargeToPreview) {
+			if f, over := p.limits.check(path, info.Size()); over {
+				p.oversized = append(p.oversized, f)
 			}
+			return nil
 		}
-		if len(p.configYamlPaths) == 0 && !info.IsDir() {
-			// This accounts for the single file preview which won't have yaml files and won't be a directory
-			fileIsIncluded = true
-		}
-
-		var isConfigFile = strings.Contains(path, "config.yml") || strings.Contains(path, "config.yaml") || strings.Contains(path, "autoconfig.yaml")
-		ext := filepath.Ext(path)
-		// Ignoring all files over 1mb for preivew and warning users if the file is over 20mb that it will be ignored in publish action as well.
-		if !info.IsDir() && info.Size() > 1000000 && !strings.Contains(path, ".git/") {
-			if path == "preview-curriculum.zip" { // don't warn on preview-curriculum, it gets read here but still cleaned up
-				return nil
-			}
-			if info.Size() > 20000000 {
-				fmt.Printf("\nWARNING: Ignoring File For Preview: File chosen/linked is too large to preview and too large to publish: %s\n", path)
-			} else {
-				fmt.Printf("\nWARNING: Ignoring File For Preview: File chosen/linked is too large to preview, but will successfully publish: %s\n", path)
-			}
+		if reason != "" {
 			return nil
 		}
 
-		if isConfigFile || fileIsIncluded || (info.IsDir() && !strings.Contains(path, ".git/") && (ext != ".git" && path != "node_modules")) {
-			if err != nil {
-				return err
-			}
-
-			// Creates a partially-populated FileHeader from an os.FileInfo
-			header, err := zip.FileInfoHeader(info)
-			if err != nil {
-				return err
-			}
-
-			// Check if baseDir has been set (from the IsDir check) and if it has not been
-			// set, update the header.Name to reflect the correct path
-			if baseDir != "" {
-				header.Name = filepath.Join(baseDir, strings.TrimPrefix(path, p.target))
-			}
-
-			// Check if the file we are iterating is a directory and update the header.Name
-			// or the header.Method appropriately
-			if info.IsDir() {
-				header.Name += string(os.PathSeparator)
-			} else {
-				header.Method = zip.Deflate
-			}
-
-			//  Add a file to the zip archive using the provided FileHeader for the file metadata
-			writer, err := archive.CreateHeader(header)
-			if err != nil {
-				return err
-			}
-
-			// Return nil if at this point if info is a directory
-			if info.IsDir() {
-				return nil
-			}
+		// Creates a partially-populated FileHeader from an os.FileInfo
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
 
-			// If it was not a directory, we open the file and copy it into the archive writer
-			// ignore zip files
-			file, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
+		// Check if baseDir has been set (from the IsDir check) and if it has not been
+		// set, update the header.Name to reflect the correct path
+		if baseDir != "" {
+			header.Name = filepath.ToSlash(filepath.Join(baseDir, strings.TrimPrefix(path, p.target)))
+		}
 
-			_, err = io.Copy(writer, file)
-			if err != nil {
-				return err
-			}
+		// Directories are added as they are, files are read and compressed after the walk
+		entry := &archiveEntry{header: *header}
+		if info.IsDir() {
+			entry.header.Name += "/"
+			entry.header.UncompressedSize64 = 0
+		} else {
+			entry.path = path
+			p.compressedFiles++
+			p.compressedBytes += info.Size()
 		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
+	if p.autoConfig != nil {
+		header := zip.FileHeader{Name: filepath.ToSlash(filepath.Join(baseDir, autoConfigFile)), Modified: time.Now()}
+		header.SetMode(0644)
+		entries = append(entries, &archiveEntry{header: header, generated: p.autoConfig})
+		p.compressedFiles++
+		p.compressedBytes += int64(len(p.autoConfig))
+	}
+
+	// S3 needs the length of an upload before it starts, so the archive is written to the workspace
+	// rather than streamed as it is compressed
+	p.archivePath = filepath.Join(p.workspace, previewArchiveFile)
+	archive, err := os.Create(p.archivePath)
+	if err != nil {
 		return err
-	})
+	}
+	p.archiveSize, err = writeArchive(ctx, archive, entries, level)
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
 
 	p.bench = &learn.CLIBenchmark{
 		Compression: time.Since(startOfCompression).Milliseconds(),
 		CmdName:     "preview",
 	}
 
-	zipSpinner.Stop()
+	stopSpinner(zipSpinner)
 	printlnGreen("√")
 
-	return err
+	if len(p.oversized) > 0 {
+		fmt.Printf("\nWARNING: Files over %s are too large to preview and were left out:\n", formatBytes(p.limits.preview()))
+		printOversizedFiles(os.Stdout, p.oversized, p.limits)
+		fmt.Printf("The limits can be changed in %s, run 'learn lint' to check the files before publishing.\n\n", projectConfigFile)
+	}
+
+	return nil
 }
 
-// uploadZip is responsible for taking a compressed preview directory and uploading it to be built by Learn.
-func (p *previewBuilder) uploadZip(tmpZipFile string) (err error) {
-	f, err := os.Open(tmpZipFile)
+// ignoredByPrefix starts the reason a file matched by a .learnignore is left out of a preview
+const ignoredByPrefix = "ignored by "
+
+// tooLargeToPreview starts the reason a file over the size limits is left out of a preview
+const tooLargeToPreview = "too large to preview"
+
+// archiveExclusion explains why the file at path, as walked from the target, is left out of the
+// preview archive. It is empty when the file is included.
+func (p *previewBuilder) archiveExclusion(path string, info os.FileInfo, resourcePaths []string) string {
+	rel, err := filepath.Rel(p.target, path)
+	if err == nil && rel != "." && p.ignore != nil {
+		if ignored, rule := p.ignore.match(rel, info.IsDir()); ignored {
+			return ignoredByPrefix + rule.String()
+		}
+	}
+	if err == nil && rel == autoConfigFile && p.autoConfig != nil {
+		return "replaced by a newly generated " + autoConfigFile
+	}
+
+	if info.IsDir() {
+		if strings.Contains(path, ".git/") || filepath.Ext(path) == ".git" || path == "node_modules" {
+			return "not previewed"
+		}
+		return ""
+	}
+
+	fileIsIncluded := false
+	for _, p := range p.configYamlPaths {
+		var configPathSplits = strings.Split(p, string(os.PathSeparator))
+		var fileName = configPathSplits[len(configPathSplits)-1]
+		if strings.Contains(path, fileName) {
+			fileIsIncluded = true
+		}
+	}
+	for _, d := range resourcePaths {
+		if strings.Contains(path, d) || strings.Contains(path, trimFirstRune(d)) {
+			fileIsIncluded = true
+		}
+	}
+	if len(p.configYamlPaths) == 0 {
+		// This accounts for the single file preview which won't have yaml files and won't be a directory
+		fileIsIncluded = true
+	}
+
+	var isConfigFile = strings.Contains(path, "config.yml") || strings.Contains(path, "config.yaml") || strings.Contains(path, "autoconfig.yaml")
+	if f, over := p.limits.check(path, info.Size()); over && !strings.Contains(path, ".git/") {
+		return p.limits.reason(f)
+	}
+	if !isConfigFile && !fileIsIncluded {
+		return "not a lesson, or linked from one"
+	}
+	return ""
+}
+
+// dryRun prints the files which would be in the preview archive, and why every other file in the
+// target is left out, without compressing or uploading anything
+func (p *previewBuilder) dryRun(w io.Writer) error {
+	resourcePaths := append(append(p.dockerPaths, p.challengePaths...), p.linkPaths...)
+
+	included := []string{}
+	var includedBytes int64
+	excluded := [][2]string{}
+	err := filepath.Walk(p.target, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(p.target, path)
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			// a single file target is relative to itself
+			name = info.Name()
+		}
+		path, name = filepath.ToSlash(path), filepath.ToSlash(name)
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		reason := p.archiveExclusion(path, info, resourcePaths)
+		switch {
+		case reason != "" && info.IsDir():
+			excluded = append(excluded, [2]string{name + "/", reason})
+			return filepath.SkipDir
+		case reason != "":
+			excluded = append(excluded, [2]string{name, reason})
+		case !info.IsDir():
+			included = append(included, name)
+			includedBytes += info.Size()
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Failed opening file (%q). Err: %v", tmpZipFile, err)
+		return err
+	}
+	if p.autoConfig != nil {
+		included = append(included, autoConfigFile)
+		includedBytes += int64(len(p.autoConfig))
 	}
-	defer f.Close()
 
-	// Create checksum of files in directory
-	// checksum, err := createChecksumFromZip(f)
-	// if err != nil {
-	// 	return bucketKey, fmt.Errorf("Failed to create a checksum for compressed file. Err: %v", err)
-	// }
+	fmt.Fprintf(w, "\nThe preview would include %d files, %s:\n", len(included), formatBytes(includedBytes))
+	for _, name := range included {
+		fmt.Fprintf(w, "  %s\n", name)
+	}
+	if len(excluded) > 0 {
+		fmt.Fprintf(w, "\nLeft out of the preview:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, e := range excluded {
+			fmt.Fprintf(tw, "  %s\t%s\n", e[0], e[1])
+		}
+		tw.Flush()
+	}
+	fmt.Fprintln(w, "\nThis was a dry run, nothing was uploaded.")
+	return nil
+}
 
+// uploadZip is responsible for uploading the compressed preview archive to be built by Learn.
+func (p *previewBuilder) uploadZip(ctx context.Context) (err error) {
 	// Start benchmark for uploadToS3
 	startOfUploadToS3 := time.Now()
 
 	// Send compressed zip file to s3
-	err = uploadToS3(f)
+	err = uploadToS3(ctx, p.archivePath)
 	if err != nil {
 		return fmt.Errorf("Failed to upload zip file to s3. Err: %v", err)
 	}
@@ -295,19 +467,17 @@ func (p *previewBuilder) uploadZip(tmpZipFile string) (err error) {
 }
 
 // buildLearnPreview triggers the Learn preview building process and montiors its completion via polling
-func (p *previewBuilder) buildLearnPreview() error {
+func (p *previewBuilder) buildLearnPreview(ctx context.Context) error {
 	fmt.Println("\nBuilding preview...")
 
 	// Start a processing spinner that runs until Learn is finished building the preview
-	s := spinner.New(spinner.CharSets[32], 100*time.Millisecond)
-	s.Color("blue")
-	s.Start()
+	s := startSpinner(32, "blue")
 
 	// Start benchmark for BuildReleaseFromS3 & PollForBuildResponse (Learn build stage)
 	startBuildAndPollRelease := time.Now()
 
 	// Let Learn know there is new preview content on s3, where it is, and to build it
-	res, err := learn.API.BuildReleaseFromS3(learn.API.Credentials.S3Key, (p.isDirectory() || p.fileContainsResourcePaths() || p.fileContainsDocker()))
+	res, err := learn.API.BuildReleaseFromS3(ctx, learn.API.Credentials.S3Key, (p.isDirectory() || p.fileContainsResourcePaths() || p.fileContainsDocker()))
 	if err != nil {
 		return fmt.Errorf("Failed to build new preview content in learn. Err: %v", err)
 	}
@@ -316,8 +486,7 @@ func (p *previewBuilder) buildLearnPreview() error {
 	// can take much longer to build, however single files build instantly so we do not need to
 	// poll for them because the call to BuildReleaseFromS3 will get a preview_url right away
 	if p.isDirectory() || p.fileContainsResourcePaths() || p.fileContainsDocker() {
-		var attempts uint8 = 30
-		res, err = learn.API.PollForBuildResponse(res.ReleaseID, p.fileInfo.IsDir(), p.fileInfo.Name(), &attempts)
+		res, err = learn.API.PollForBuildResponse(ctx, res.ReleaseID, p.fileInfo.IsDir(), p.fileInfo.Name())
 		if err != nil {
 			return fmt.Errorf("Failed to poll Learn for your new preview build. Err: %v", err)
 		}
@@ -327,11 +496,11 @@ func (p *previewBuilder) buildLearnPreview() error {
 	p.bench.LearnBuild = time.Since(startBuildAndPollRelease).Milliseconds()
 	p.bench.TotalCmdTime = time.Since(p.startOfCmd).Milliseconds()
 
-	// Set final message for display
-	s.FinalMSG = fmt.Sprintf("Successfully uploaded your preview! You can find your content at: %s\n", res.PreviewURL)
+	p.preview = res
 
 	// Stop the processing spinner
-	s.Stop()
+	stopSpinner(s)
+	fmt.Printf("Successfully uploaded your preview! You can find your content at: %s\n", res.PreviewURL)
 	printlnGreen("√")
 
 	if OpenPreview {
@@ -373,97 +542,92 @@ func (p *previewBuilder) isDirectory() bool {
 }
 
 // previewCmd is executed when the `learn preview` command is used. Preview's concerns:
-// 1. Compress directory/file into target location.
-// 2. Defer cleaning up the file after command is finished.
-// 3. Create a checksum for the zip file.
-// 4. Upload the zip file to s3.
-// 5. Notify learn that new content is available for building.
-// 6. Handle progress bar for s3 upload.
+// 1. Compress the files of the directory/file concurrently into a zip in the workspace.
+// 2. Defer cleaning up any copied files after command is finished.
+// 3. Upload the zip to s3.
+// 4. Notify learn that new content is available for building.
+// 5. Handle progress bar for s3 upload.
 var previewCmd = &cobra.Command{
-	Use:   "preview [options] <directory|file_path>",
+	Use:   "preview [options] <directory|file_path>...",
 	Short: "Uploads content and builds a preview.",
 	Long: `
 The preview command takes a path to either a directory or a single file and
 uploads the content to Learn through the Learn API. Learn will build the
 preview and return/open the preview URL when it is complete.
+
+A single unit directory, or several markdown files, are previewed on their own
+with a config listing only those lessons:
+
+  learn preview units/03-loops
+  learn preview units/01-intro/lesson.md units/02-sql/challenge.md
 	`,
-	Args: cobra.ExactArgs(1),
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		tmpZipFile := "preview-curriculum.zip"
-		previewer, err := NewPreviewBuilder(args)
-		if err != nil {
-			previewCmdError(fmt.Sprintf("%v", err), tmpZipFile)
-			return
-		}
-
-		err = previewer.collectPaths()
-		if err != nil {
-			previewCmdError(fmt.Sprintf("%v", err), tmpZipFile)
-			return
+		ctx := cmd.Context()
+		if _, err := compressionLevel(PreviewCompression); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 
-		if previewer.containsAnyResources() {
-			err = previewer.buildAlternateTarget()
-			if err != nil {
-				previewCmdError(fmt.Sprintf("%v", err), tmpZipFile)
-				return
+		if PreviewCI || PreviewChanged {
+			if len(args) > 1 {
+				fmt.Fprintln(os.Stderr, "Only one block can be previewed with --ci or --changed")
+				os.Exit(1)
 			}
-		}
-
-		if previewer.containsAnyResources() || previewer.isDirectory() {
-			err = previewer.setConfigYaml()
-			if err != nil {
-				previewCmdError(fmt.Sprintf("%v", err), tmpZipFile)
-				return
+			target := "."
+			if len(args) == 1 {
+				target = args[0]
+			}
+			if PreviewCI {
+				previewCI(ctx, target)
+			} else {
+				previewChanged(ctx, target)
 			}
+			return
+		}
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: learn preview [options] <directory|file_path>...")
+			os.Exit(1)
 		}
 
-		err = previewer.compressDirectory(tmpZipFile)
+		startRun("preview", strings.Join(args, " "))
+		target, lessons, err := previewTarget(args)
 		if err != nil {
-			previewCmdError(fmt.Sprintf("Failed to compress provided directory (%s). Err: %v", previewer.target, err), tmpZipFile)
+			previewCmdError(ctx, err.Error())
 			return
 		}
-
-		// Removes artifacts on user's machine
-		defer removeArtifacts(tmpZipFile)
-
-		err = previewer.uploadZip(tmpZipFile)
+		previewer, err := runPreview(ctx, target, lessons)
 		if err != nil {
-			previewCmdError(fmt.Sprintf("%v", err), tmpZipFile)
+			previewCmdError(ctx, err.Error())
 			return
 		}
-		err = previewer.buildLearnPreview()
-		if err != nil {
-			previewCmdError(fmt.Sprintf("%v", err), tmpZipFile)
+		if PreviewDryRun {
+			finishRun()
 			return
 		}
 
-		err = learn.API.SendMetadataToLearn(&learn.CLIBenchmarkPayload{
-			CLIBenchmark: previewer.bench,
-		})
-
-		if err != nil {
-			removeArtifacts(tmpZipFile)
-			learn.API.NotifySlack(err)
-			os.Exit(1)
-		}
+		sendBenchmark(ctx, previewer.bench)
+		runResult.PreviewURL = previewer.preview.PreviewURL
+		runResult.ReleaseID = previewer.preview.ReleaseID
+		runResult.Warnings = previewer.preview.SyncWarnings
+		finishRun()
 	},
 }
 
-// createNewTarget will set up and create everything needed for single file previews if they are needed.
+// createNewTarget will set up and create everything needed for single file previews in dir if they are needed.
 // Returns a string representing the source name which if not single file tmp dir is needed, will return the original
-func createNewTarget(target string, challengePaths, linkPaths, dockerPaths []string) (string, error) {
-	substringPaths, err := copyLinks(target, linkPaths)
+func createNewTarget(dir, target string, challengePaths, linkPaths, dockerPaths []string) (string, error) {
+	substringPaths, err := copyLinks(dir, target, linkPaths)
 	if err != nil {
 		return "", err
 	}
 
-	err = copyDockerPaths(target, dockerPaths)
+	err = copyDockerPaths(dir, target, dockerPaths)
 	if err != nil {
 		return "", err
 	}
 
-	err = copyChallengePaths(target, challengePaths)
+	err = copyChallengePaths(dir, target, challengePaths)
 	if err != nil {
 		return "", err
 	}
@@ -473,7 +637,7 @@ func createNewTarget(target string, challengePaths, linkPaths, dockerPaths []str
 	srcMDFile := srcArray[len(srcArray)-1]
 
 	// Copy original single markdown file into the base of our new tmp dir
-	newTarget := tmpSingleFileDir + "/" + srcMDFile
+	newTarget := dir + "/" + srcMDFile
 	err = Copy(target, newTarget)
 	if err != nil {
 		return "", err
@@ -498,16 +662,116 @@ func createNewTarget(target string, challengePaths, linkPaths, dockerPaths []str
 				return "", fmt.Errorf("Could not write copied target file with cleaned up links: %s", err)
 			}
 		}
-		return tmpSingleFileDir, nil
+		return dir, nil
 	}
 
 	return target, nil
 }
 
+// createLessonsTarget is createNewTarget for many lessons at once. Each lesson, relative to blockRoot,
+// is copied into targetDir along with the links, challenge files and docker directories it
+// uses, all keeping their paths in the block so relative links still work. A config.yaml listing
+// only the lessons is written with them. The resources copied are returned.
+func createLessonsTarget(targetDir, blockRoot string, lessons []string) (dockerPaths, challengePaths, linkPaths []string, err error) {
+	for _, lesson := range lessons {
+		lessonPath := filepath.Join(blockRoot, lesson)
+		docker, challenges, links, err := resourcesFromTarget(lessonPath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if err = copyIntoTarget(targetDir, lessonPath, lesson); err != nil {
+			return nil, nil, nil, err
+		}
+
+		// links are relative to the lesson
+		for _, link := range links {
+			linkPath := filepath.Join(filepath.Dir(lesson), filepath.FromSlash(strings.TrimPrefix(link, "/")))
+			if outsideDir(linkPath) {
+				log.Printf("Link outside of the block not included '%s'\n", link)
+				continue
+			}
+			err = copyIntoTarget(targetDir, filepath.Join(blockRoot, linkPath), linkPath)
+			if os.IsNotExist(err) {
+				log.Printf("Link not found with path '%s'\n", link)
+				continue
+			}
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			linkPaths = append(linkPaths, filepath.ToSlash(linkPath))
+		}
+
+		// challenge files and docker directories start at the root of the block, or a parent of it
+		for _, challenge := range challenges {
+			_, found := fileFromParents(lessonPath, challenge)
+			if found == "" {
+				log.Printf("challenge file not found with path '%s'\n", challenge)
+				continue
+			}
+			if err = copyIntoTarget(targetDir, found, trimFirstRune(challenge)); err != nil {
+				return nil, nil, nil, err
+			}
+			challengePaths = append(challengePaths, challenge)
+		}
+		for _, dir := range docker {
+			fileInfo, found := fileFromParents(lessonPath, dir)
+			if found == "" {
+				log.Printf("docker_directory_path not found with path '%s'\n", dir)
+				continue
+			}
+			if !fileInfo.IsDir() {
+				return nil, nil, nil, fmt.Errorf("docker_directory_path %s is not a directory", dir)
+			}
+			fmt.Printf("Including docker_directory_path: %s\n", dir)
+			ignorePatterns, err := DockerIgnorePatterns(found)
+			if err != nil {
+				fmt.Fprint(os.Stderr, err.Error())
+			}
+			err = CopyDirectoryContents(found, filepath.Join(targetDir, filepath.FromSlash(trimFirstRune(dir))), ignorePatterns)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			dockerPaths = append(dockerPaths, dir)
+		}
+	}
+
+	config, err := lessonsConfigYaml(blockRoot, lessons)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	configYaml, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	err = ioutil.WriteFile(filepath.Join(targetDir, "config.yaml"), configYaml, 0666)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return uniq(dockerPaths), uniq(challengePaths), uniq(linkPaths), nil
+}
+
+// copyIntoTarget copies the file at src to path in dir, creating its directories
+func copyIntoTarget(dir, src, path string) error {
+	dst := filepath.Join(dir, path)
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), os.FileMode(0777)); err != nil {
+		return err
+	}
+	return Copy(src, dst)
+}
+
+// outsideDir reports if a relative path leads out of the directory it is relative to
+func outsideDir(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // copyLinks is used when creating a new target. It iterates over given links, creates necessary
-// directories for the link, then copies the link into the new temproary target directory. Links which
+// directories for the link, then copies the link into the new temproary target directory dir. Links which
 // must be rewritten in the original target are returned if they contain '..'
-func copyLinks(target string, linkPaths []string) (substringPaths []string, err error) {
+func copyLinks(dir, target string, linkPaths []string) (substringPaths []string, err error) {
 	for _, filePath := range linkPaths {
 		if !strings.HasPrefix(filePath, "/") {
 			filePath = fmt.Sprintf("/%s", filePath)
@@ -523,12 +787,12 @@ func copyLinks(target string, linkPaths []string) (substringPaths []string, err
 			}
 		}
 		// We need to modify the actual markdown file so it no longer has `..` in links, since we're putting
-		// everything in the tmpSingleFileDir
+		// everything in the temporary target directory
 		if containsPeriodPeriod {
 			substringPaths = append(substringPaths, filePath)
 		}
 
-		linkDirs, err := createLinkDirectories(pathArray)
+		linkDirs, err := createLinkDirectories(dir, pathArray)
 		if err != nil {
 			return []string{}, err
 		}
@@ -552,7 +816,7 @@ func copyLinks(target string, linkPaths []string) (substringPaths []string, err
 			log.Printf("Link not found with path '%s'\n", sourceLinkPath)
 		} else {
 			// Copy the actual image into our new temp directory in it's appropriate spot
-			err = Copy(sourceLinkPath, tmpSingleFileDir+linkDirs+"/"+linkName)
+			err = Copy(sourceLinkPath, dir+linkDirs+"/"+linkName)
 			if err != nil {
 				return []string{}, err
 			}
@@ -562,8 +826,8 @@ func copyLinks(target string, linkPaths []string) (substringPaths []string, err
 	return
 }
 
-// copyDockerPaths copies the contents of docker directory paths into the temporary directory
-func copyDockerPaths(target string, dockerPaths []string) (err error) {
+// copyDockerPaths copies the contents of docker directory paths into the temporary directory dir
+func copyDockerPaths(dir, target string, dockerPaths []string) (err error) {
 	// iterate over docker directories as their contents must be recursively copied
 	for _, dirPath := range dockerPaths {
 		fmt.Printf("Including docker_directory_path: %s\n", dirPath)
@@ -585,7 +849,7 @@ func copyDockerPaths(target string, dockerPaths []string) (err error) {
 					fmt.Fprint(os.Stderr, err.Error())
 				}
 
-				err = CopyDirectoryContents(newDirPath, tmpSingleFileDir+"/"+dirPath, ignorePatterns)
+				err = CopyDirectoryContents(newDirPath, dir+"/"+dirPath, ignorePatterns)
 				if err != nil {
 					return err
 				}
@@ -599,7 +863,7 @@ func copyDockerPaths(target string, dockerPaths []string) (err error) {
 				fmt.Fprint(os.Stderr, err.Error())
 			}
 
-			err = CopyDirectoryContents(dirPath, tmpSingleFileDir+"/"+dirPath, ignorePatterns)
+			err = CopyDirectoryContents(dirPath, dir+"/"+dirPath, ignorePatterns)
 			if err != nil {
 				return err
 			}
@@ -609,13 +873,14 @@ func copyDockerPaths(target string, dockerPaths []string) (err error) {
 	return nil
 }
 
-// copyChallengePaths takes
-func copyChallengePaths(target string, challengePaths []string) (err error) {
+// copyChallengePaths copies the challenge files into the temporary directory dir, looking for them
+// from the target up through its parents
+func copyChallengePaths(dir, target string, challengePaths []string) (err error) {
 	for _, filePath := range challengePaths {
 		// Ex. /tests/dir/my_neat_test.js -> ["tests", "dir", "my_neat_tests.js"]
 		pathArray := strings.Split(filePath, "/")
 
-		linkDirs, err := createLinkDirectories(pathArray)
+		linkDirs, err := createLinkDirectories(dir, pathArray)
 		if err != nil {
 			return err
 		}
@@ -631,7 +896,7 @@ func copyChallengePaths(target string, challengePaths []string) (err error) {
 			_, useThisPath := fileFromParents(target, filePath)
 
 			if useThisPath != "" {
-				err = Copy(useThisPath, tmpSingleFileDir+linkDirs+"/"+fileName)
+				err = Copy(useThisPath, dir+linkDirs+"/"+fileName)
 				if err != nil {
 					return err
 				}
@@ -640,7 +905,7 @@ func copyChallengePaths(target string, challengePaths []string) (err error) {
 			}
 		} else {
 			// Copy the actual image into our new temp directory in it's appropriate spot
-			err = Copy(filePath, tmpSingleFileDir+linkDirs+"/"+fileName)
+			err = Copy(filePath, dir+linkDirs+"/"+fileName)
 			if err != nil {
 				return err
 			}
@@ -650,7 +915,7 @@ func copyChallengePaths(target string, challengePaths []string) (err error) {
 	return nil
 }
 
-func createLinkDirectories(pathArray []string) (linkDirs string, err error) {
+func createLinkDirectories(dir string, pathArray []string) (linkDirs string, err error) {
 	// create an linkDirs var and depending on how long the image file path is, update it to include
 	// everything up to the image itself
 	if len(pathArray) == 1 {
@@ -664,7 +929,7 @@ func createLinkDirectories(pathArray []string) (linkDirs string, err error) {
 	}
 
 	// Create appropriate directory for each link using the linkDirs
-	err = os.MkdirAll(tmpSingleFileDir+linkDirs, os.FileMode(0777))
+	err = os.MkdirAll(dir+linkDirs, os.FileMode(0777))
 	if err != nil {
 		return "", err
 	}
@@ -672,17 +937,88 @@ func createLinkDirectories(pathArray []string) (linkDirs string, err error) {
 	return
 }
 
-// previewCmdError is a small wrapper for all errors within the preview command. It ensures
-// artifacts are cleaned up with a call to removeArtifacts
-func previewCmdError(msg, tmpZipFile string) {
-	fmt.Fprintln(os.Stderr, msg)
-	removeArtifacts(tmpZipFile)
-	learn.API.NotifySlack(errors.New(msg))
-	os.Exit(1)
+// runPreview builds a preview of target on Learn, timing each phase in runTimings. When lessons are
+// given, only those lessons in the target directory are previewed.
+func runPreview(ctx context.Context, target string, lessons []string) (*previewBuilder, error) {
+	phase := runTimings.begin("setup")
+	previewer, err := NewPreviewBuilder(ctx, []string{target})
+	if err != nil {
+		return previewer, err
+	}
+	// Removes the scratch files, also when the preview is interrupted and ctx is cancelled
+	defer previewer.removeWorkspace()
+	previewer.lessons = lessons
+	phase.end()
+	if !PreviewDryRun {
+		fmt.Printf("Using Learn profile %s\n", learnProfile)
+	}
+
+	phase = runTimings.begin("paths")
+	err = previewer.collectPaths()
+	if err != nil {
+		return previewer, err
+	}
+
+	if previewer.containsAnyResources() || len(previewer.lessons) > 0 {
+		err = previewer.buildAlternateTarget()
+		if err != nil {
+			return previewer, err
+		}
+	}
+	phase.end()
+
+	if previewer.containsAnyResources() || previewer.isDirectory() {
+		phase = runTimings.begin("config")
+		err = previewer.setConfigYaml()
+		if err != nil {
+			return previewer, err
+		}
+		phase.end()
+	}
+
+	if PreviewDryRun {
+		return previewer, previewer.dryRun(os.Stdout)
+	}
+
+	phase = runTimings.begin("compress")
+	err = previewer.compressDirectory(ctx)
+	if err != nil {
+		return previewer, fmt.Errorf("Failed to compress provided directory (%s). Err: %v", previewer.target, err)
+	}
+	phase.end()
+	phase.Files, phase.Bytes = previewer.compressedFiles, previewer.compressedBytes
+
+	phase = runTimings.begin("upload")
+	phase.Bytes = previewer.archiveSize
+	err = previewer.uploadZip(ctx)
+	if err != nil {
+		return previewer, err
+	}
+	phase.end()
+
+	phase = runTimings.begin("build")
+	err = previewer.buildLearnPreview(ctx)
+	if err != nil {
+		return previewer, err
+	}
+	phase.end()
+
+	return previewer, nil
 }
 
-// printlnGreen simply prints a green string
+// previewCmdError is a small wrapper for all errors within the preview command. It reports the
+// error when telemetry is on.
+func previewCmdError(ctx context.Context, msg string) {
+	reportError(ctx, errors.New(msg))
+	failRun(msg)
+}
+
+// printlnGreen simply prints a green string, without color when the output is not interactive
 func printlnGreen(text string) {
+	if !interactive() {
+		fmt.Println(text)
+		return
+	}
 	fmt.Printf("\033[32m%s\033[0m\n", text)
 }
 
@@ -705,46 +1041,6 @@ func resourcesFromTarget(target string) (uniqueDockerPaths, challengePaths, link
 	return uniq(dockerDirectoryPaths), uniq(challengePaths), uniq(m.Links), nil
 }
 
-func newfileUploadRequest(uri string, file *os.File) (*http.Request, error) {
-	fileContents, err := ioutil.ReadAll(file)
-	if err != nil {
-		return nil, err
-	}
-
-	body := new(bytes.Buffer)
-	_, err = body.Write(fileContents)
-	if err != nil {
-		return nil, err
-	}
-
-	return http.NewRequest("PUT", uri, body)
-}
-
-// uploadToS3 takes a file and it's checksum and uploads it to s3 in the appropriate bucket/key
-func uploadToS3(file *os.File) error {
-	fmt.Println("Uploading assets to Learn...")
-	uploadSpinner := spinner.New(spinner.CharSets[32], 100*time.Millisecond)
-	uploadSpinner.Color("green")
-	uploadSpinner.Start()
-
-	// Parse multipart upload for bucket and presigned URL
-	request, err := newfileUploadRequest(learn.API.Credentials.PresignedUrl, file)
-	if err != nil {
-		return err
-	}
-	client := &http.Client{Timeout: time.Second * 180}
-	resp, err := client.Do(request)
-	if err != nil {
-		return err
-	} else if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Uploading asset produced non-200 status code: %d\n", resp.StatusCode)
-	}
-	uploadSpinner.Stop()
-	printlnGreen("√")
-
-	return nil
-}
-
 // createChecksumFromZip takes a pointer to a file and creates a sha256 checksum
 // of the content. We use this for naming the s3 bucket key so that we don't write
 // duplicates to s3. The call to io.Copy actually consumes the read position of
@@ -772,24 +1068,6 @@ func createChecksumFromZip(file *os.File) (string, error) {
 	return checksum, nil
 }
 
-// removeArtifacts removes the tmp zipfile and the tmp single file preview directory (if there
-// was one) that were created for uploading to s3 and including local images. We wouldn't
-// want to leave artifacts on user's machines
-func removeArtifacts(tmpZipFile string) {
-	err := os.Remove(tmpZipFile)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Sorry, we had trouble cleaning up the zip file created for curriculum preview")
-	}
-
-	// Remove tmpSingleFileDir if it exists at this point
-	if _, err := os.Stat(tmpSingleFileDir); !os.IsNotExist(err) {
-		err = os.RemoveAll(tmpSingleFileDir)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Sorry, we had trouble cleaning up the tmp single file preview directory")
-		}
-	}
-}
-
 // Copy the src file to target dest. Any existing file will be overwritten and will not copy file attributes.
 func Copy(src, dst string) error {
 	in, err := os.Open(src)
@@ -829,7 +1107,20 @@ func DockerIgnorePatterns(src string) ([]string, error) {
 	return strings.Split(string(ignoreFile), "\n"), nil
 }
 
+// CopyDirectoryContents copies the directory src to dst, leaving out what the lines of its
+// .dockerignore in ignorePatterns exclude
 func CopyDirectoryContents(src, dst string, ignorePatterns []string) error {
+	ignore, err := di.New(di.Docker, ignorePatterns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error while parsing at: %s\n", err)
+		ignore, _ = di.New(di.Docker, nil)
+	}
+	return copyDirectoryContents(src, src, dst, ignore)
+}
+
+// copyDirectoryContents copies src, a directory under root, to dst leaving out the files ignore
+// matches relative to root. The files docker needs to build and run a challenge are always copied.
+func copyDirectoryContents(root, src, dst string, ignore *di.Matcher) error {
 	srcInfo, err := os.Stat(src)
 	if err != nil {
 		return err
@@ -851,33 +1142,24 @@ func CopyDirectoryContents(src, dst string, ignorePatterns []string) error {
 	for _, file := range files {
 		source := filepath.Join(src, file.Name())
 		destination := filepath.Join(dst, file.Name())
-		ignore := false
-		for _, pattern := range ignorePatterns {
-			var alwaysAllowBool = false
-			for _, aa := range alwaysAllowSlice {
-				if aa == pattern {
-					alwaysAllowBool = true
-				}
-			}
-			if alwaysAllowBool {
-				continue
-			}
-			localizedPattern := src + "/" + pattern
-			matched, err := di.IgnoreMatches(localizedPattern, source)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "error while parsing at: %s", err)
-			}
-			if matched {
-				ignore = matched
-				break
+
+		alwaysAllow := false
+		for _, aa := range alwaysAllowSlice {
+			if aa == file.Name() {
+				alwaysAllow = true
 			}
 		}
-		if ignore {
+		rel, err := filepath.Rel(root, source)
+		if err != nil {
+			return err
+		}
+		// the contents of an ignored directory are only checked when a rule could re-include them
+		if !alwaysAllow && ignore.Ignored(rel, file.IsDir()) && !(file.IsDir() && ignore.HasExceptions()) {
 			continue
 		}
 
 		if file.IsDir() {
-			err = CopyDirectoryContents(source, destination, ignorePatterns)
+			err = copyDirectoryContents(root, source, destination, ignore)
 			if err != nil {
 				return err
 			}
@@ -898,13 +1180,16 @@ func CopyDirectoryContents(src, dst string, ignorePatterns []string) error {
 func (p *previewBuilder) parseConfigAndGatherPaths() error {
 	config := ConfigYaml{}
 
-	configYaml, _ := findConfig(p.target)
-	data, err := ioutil.ReadFile(configYaml)
-	if err != nil {
-		return err
+	data := p.autoConfig
+	if data == nil {
+		configYaml, _ := findConfig(p.target)
+		var err error
+		if data, err = ioutil.ReadFile(configYaml); err != nil {
+			return err
+		}
 	}
 
-	err = yaml.Unmarshal([]byte(data), &config)
+	err := yaml.Unmarshal(data, &config)
 	if err != nil {
 		return err
 	}
@@ -969,10 +1254,10 @@ func fileFromParents(target, filePath string) (file os.FileInfo, path string) {
 	// has to be greater than 1 because an absolute path split on / always has a blank entry at 0 index
 	for len(absDir) > 1 {
 		fileLocation := strings.Join(absDir, "/") + filePath
-		file, parentExists := os.Stat(fileLocation)
+		info, parentExists := os.Stat(fileLocation)
 
 		if parentExists == nil {
-			file = file
+			file = info
 			path = fileLocation
 			break
 		} else {
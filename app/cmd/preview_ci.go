@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gSchool/glearn-cli/git"
+)
+
+// PreviewCI is the flag to preview the changes of a pull request from CI
+var PreviewCI bool
+
+// PreviewChanged is the flag to preview only the lessons changed on the current branch
+var PreviewChanged bool
+
+// PreviewDryRun is the flag to list what a preview would include, and why files are left out, without uploading
+var PreviewDryRun bool
+
+// PreviewBase is the flag for the ref changes are found against with --ci or --changed
+var PreviewBase string
+
+// PreviewCommentFile is the flag for the file the markdown of preview links is written to with --ci
+var PreviewCommentFile string
+
+// maxCIFilePreviews is the most changed lessons previewed one at a time, more preview the whole block
+const maxCIFilePreviews = 5
+
+// ciCommentMarker starts the markdown written with --ci, so a CI job can find and update its comment
+const ciCommentMarker = "<!-- learn-preview -->"
+
+// ciPreview is the preview of one target built with --ci
+type ciPreview struct {
+	Target     string `json:"target"`
+	PreviewURL string `json:"preview_url,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// previewCI previews the lessons in blockDir changed since the base ref, or the whole block when
+// anything else changed, then writes markdown linking to the previews for a pull request comment.
+// It never prompts or opens a browser, and exits 1 when any preview failed.
+func previewCI(ctx context.Context, blockDir string) {
+	OpenPreview = false
+	startRun("preview", blockDir)
+	runResult.Previews = []ciPreview{}
+
+	base := previewBaseRef()
+	changed, err := changedInBlock(blockDir, base)
+	if err != nil {
+		failRun(fmt.Sprintf("Could not find the files changed since %s, make sure it has been fetched. Err: %v", base, err))
+		return
+	}
+
+	targets := ciTargets(blockDir, changed)
+	if len(targets) == 0 {
+		fmt.Printf("No curriculum in %s changed since %s\n", blockDir, base)
+	}
+
+	failed := false
+	for _, target := range targets {
+		fmt.Printf("\nPreviewing %s\n", target)
+		preview := ciPreview{Target: target}
+		previewer, err := runPreview(ctx, target, nil)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			reportError(ctx, err)
+			preview.Error = err.Error()
+			failed = true
+		} else {
+			sendBenchmark(ctx, previewer.bench)
+			preview.PreviewURL = previewer.preview.PreviewURL
+			runResult.Warnings = append(runResult.Warnings, previewer.preview.SyncWarnings...)
+		}
+		runResult.Previews = append(runResult.Previews, preview)
+	}
+
+	if err := writeCIComment(runResult.Previews, base, blockDir); err != nil {
+		failRun(fmt.Sprintf("Could not write the preview links to %s. Err: %v", PreviewCommentFile, err))
+		return
+	}
+	if failed {
+		failRun("Some previews could not be built")
+		return
+	}
+	finishRun()
+}
+
+// previewChanged builds one preview of only the lessons in blockDir changed since the base ref, with
+// a config listing just those lessons, so reviewers of a large block see only what changed
+func previewChanged(ctx context.Context, blockDir string) {
+	startRun("preview", blockDir)
+
+	base := previewBaseRef()
+	changed, err := changedInBlock(blockDir, base)
+	if err != nil {
+		failRun(fmt.Sprintf("Could not find the files changed since %s, make sure it has been fetched. Err: %v", base, err))
+		return
+	}
+
+	lessons := []string{}
+	for _, f := range changed {
+		if strings.HasSuffix(f, ".md") {
+			rel, err := filepath.Rel(blockDir, f)
+			if err != nil {
+				failRun(fmt.Sprintf("Could not find %s in %s. Err: %v", f, blockDir, err))
+				return
+			}
+			// files and directories starting with __ are never in a preview
+			if strings.HasPrefix(rel, "__") || strings.Contains(filepath.ToSlash(rel), "/__") {
+				continue
+			}
+			lessons = append(lessons, rel)
+		}
+	}
+	if len(lessons) == 0 {
+		fmt.Printf("No lessons in %s changed since %s, there is nothing to preview\n", blockDir, base)
+		finishRun()
+		return
+	}
+
+	fmt.Printf("Previewing the lessons changed since %s:\n", base)
+	for _, lesson := range lessons {
+		fmt.Printf("  %s\n", filepath.ToSlash(lesson))
+	}
+	previewer, err := runPreview(ctx, blockDir, lessons)
+	if err != nil {
+		previewCmdError(ctx, err.Error())
+		return
+	}
+	if PreviewDryRun {
+		finishRun()
+		return
+	}
+
+	sendBenchmark(ctx, previewer.bench)
+	runResult.PreviewURL = previewer.preview.PreviewURL
+	runResult.ReleaseID = previewer.preview.ReleaseID
+	runResult.Warnings = previewer.preview.SyncWarnings
+	finishRun()
+}
+
+// previewBaseRef is the ref changes are found against with --ci or --changed: the --base flag, the target branch of a GitHub
+// pull request or GitLab merge request, and origin/master otherwise
+func previewBaseRef() string {
+	if PreviewBase != "" {
+		return PreviewBase
+	}
+	for _, env := range []string{"GITHUB_BASE_REF", "CI_MERGE_REQUEST_TARGET_BRANCH_NAME"} {
+		if branch := os.Getenv(env); branch != "" {
+			return "origin/" + branch
+		}
+	}
+	return "origin/master"
+}
+
+// changedInBlock lists the files in blockDir changed since base, relative to the working directory
+func changedInBlock(blockDir, base string) ([]string, error) {
+	files, err := gitRepo.ChangedFiles(base)
+	if err != nil {
+		return nil, err
+	}
+	return filesInBlock(gitRepo, blockDir, files)
+}
+
+// filesInBlock keeps the files from repo, relative to its top level directory, which are in
+// blockDir, returning them relative to the working directory
+func filesInBlock(repo git.Repo, blockDir string, files []string) ([]string, error) {
+	top, err := repo.TopLevelDir()
+	if err != nil {
+		return nil, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	block, err := filepath.Abs(blockDir)
+	if err != nil {
+		return nil, err
+	}
+	// Compare resolved paths, the top level from git has any symlinks resolved
+	if resolved, err := filepath.EvalSymlinks(block); err == nil {
+		block = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+		cwd = resolved
+	}
+
+	changed := []string{}
+	for _, f := range files {
+		path := filepath.Join(top, filepath.FromSlash(f))
+		if rel, err := filepath.Rel(block, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+		changed = append(changed, path)
+	}
+	return changed, nil
+}
+
+// ciTargets decides what to preview for the changed files. Changed lessons are previewed one at a
+// time, but the whole block is previewed when other files such as the config or images changed, or
+// when more than maxCIFilePreviews lessons changed.
+func ciTargets(blockDir string, changed []string) []string {
+	lessons := []string{}
+	for _, f := range changed {
+		if !strings.HasSuffix(f, ".md") && !strings.HasSuffix(f, ".ipynb") {
+			return []string{blockDir}
+		}
+		lessons = append(lessons, f)
+	}
+	if len(lessons) > maxCIFilePreviews {
+		return []string{blockDir}
+	}
+	return lessons
+}
+
+// writeCIComment writes the markdown for the previews to --comment-file, or to stdout for text output
+func writeCIComment(previews []ciPreview, base, blockDir string) error {
+	if PreviewCommentFile == "" {
+		if !jsonOutput() {
+			fmt.Fprintln(resultOut)
+			ciComment(resultOut, previews, base, blockDir)
+		}
+		return nil
+	}
+
+	f, err := os.Create(PreviewCommentFile)
+	if err != nil {
+		return err
+	}
+	ciComment(f, previews, base, blockDir)
+	return f.Close()
+}
+
+// ciComment writes markdown linking to each preview, and the error of any preview which failed
+func ciComment(w io.Writer, previews []ciPreview, base, blockDir string) {
+	fmt.Fprintf(w, "%s\n### Learn previews\n\n", ciCommentMarker)
+	if len(previews) == 0 {
+		fmt.Fprintf(w, "No curriculum changed since `%s`.\n", base)
+		return
+	}
+
+	fmt.Fprintf(w, "Curriculum changed since `%s`:\n\n", base)
+	for _, p := range previews {
+		name := filepath.ToSlash(p.Target)
+		if p.Target == blockDir {
+			name = "Whole block"
+		}
+		if p.Error != "" {
+			fmt.Fprintf(w, "- %s: preview failed, %s\n", name, strings.SplitN(p.Error, "\n", 2)[0])
+			continue
+		}
+		fmt.Fprintf(w, "- [%s](%s)\n", name, p.PreviewURL)
+	}
+}
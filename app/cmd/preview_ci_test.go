@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gSchool/glearn-cli/git"
+)
+
+func Test_previewBaseRef(t *testing.T) {
+	defer func() { PreviewBase = "" }()
+	t.Setenv("GITHUB_BASE_REF", "")
+	t.Setenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "")
+
+	if base := previewBaseRef(); base != "origin/master" {
+		t.Errorf("previewBaseRef should default to origin/master, got %s", base)
+	}
+	t.Setenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "develop")
+	if base := previewBaseRef(); base != "origin/develop" {
+		t.Errorf("previewBaseRef should use the GitLab target branch, got %s", base)
+	}
+	t.Setenv("GITHUB_BASE_REF", "main")
+	if base := previewBaseRef(); base != "origin/main" {
+		t.Errorf("previewBaseRef should use the GitHub base branch, got %s", base)
+	}
+	PreviewBase = "upstream/main"
+	if base := previewBaseRef(); base != "upstream/main" {
+		t.Errorf("previewBaseRef should prefer the --base flag, got %s", base)
+	}
+}
+
+func Test_changedInBlock(t *testing.T) {
+	defer func() { gitRepo = git.NewNative("") }()
+	top, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitRepo = &git.Fake{TopLevel: top, Changed: []string{
+		"fixtures/test-block-auto-config/units/test.md",
+		"fixtures/test-block-no-config/README.md",
+		"README.md",
+	}}
+
+	changed, err := changedInBlock("../../fixtures/test-block-auto-config", "origin/master")
+	if err != nil {
+		t.Fatalf("changedInBlock errored: %v", err)
+	}
+	expected := []string{filepath.FromSlash("../../fixtures/test-block-auto-config/units/test.md")}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("changedInBlock should list only the block's files relative to the working directory, got %q", changed)
+	}
+
+	gitRepo = &git.Fake{TopLevel: top, Errs: map[string]error{"ChangedFiles": os.ErrNotExist}}
+	if _, err := changedInBlock(".", "origin/missing"); err == nil {
+		t.Errorf("changedInBlock should return the error from git")
+	}
+}
+
+func Test_ciTargets(t *testing.T) {
+	lessons := []string{"unit-1/intro.md", "unit-1/notebook.ipynb"}
+	if targets := ciTargets("block", lessons); !reflect.DeepEqual(targets, lessons) {
+		t.Errorf("changed lessons should be previewed one at a time, got %q", targets)
+	}
+	if targets := ciTargets("block", []string{}); len(targets) != 0 {
+		t.Errorf("nothing should be previewed when nothing changed, got %q", targets)
+	}
+	if targets := ciTargets("block", append(lessons, "config.yaml")); !reflect.DeepEqual(targets, []string{"block"}) {
+		t.Errorf("a changed config should preview the whole block, got %q", targets)
+	}
+
+	many := []string{}
+	for i := 0; i <= maxCIFilePreviews; i++ {
+		many = append(many, filepath.Join("unit-1", string(rune('a'+i))+".md"))
+	}
+	if targets := ciTargets("block", many); !reflect.DeepEqual(targets, []string{"block"}) {
+		t.Errorf("more than %d changed lessons should preview the whole block, got %q", maxCIFilePreviews, targets)
+	}
+}
+
+func Test_ciComment(t *testing.T) {
+	var out bytes.Buffer
+	ciComment(&out, []ciPreview{}, "origin/main", ".")
+	if !strings.HasPrefix(out.String(), ciCommentMarker) || !strings.Contains(out.String(), "No curriculum changed since `origin/main`") {
+		t.Errorf("ciComment should say nothing changed, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	ciComment(&out, []ciPreview{
+		{Target: "unit-1/intro.md", PreviewURL: "https://learn-2.galvanize.com/previews/1"},
+		{Target: "unit-1/broken.md", Error: "Failed to poll Learn for your new preview build.\nmore detail"},
+		{Target: ".", PreviewURL: "https://learn-2.galvanize.com/previews/2"},
+	}, "origin/main", ".")
+	for _, line := range []string{
+		"- [unit-1/intro.md](https://learn-2.galvanize.com/previews/1)",
+		"- unit-1/broken.md: preview failed, Failed to poll Learn for your new preview build.",
+		"- [Whole block](https://learn-2.galvanize.com/previews/2)",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("ciComment should contain %q, got:\n%s", line, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "more detail") {
+		t.Errorf("ciComment should only include the first line of an error")
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// previewTarget finds what to preview for the arguments to preview. A block or a single file is
+// previewed as it is. Several lessons, or a unit which cannot be previewed without its block, are
+// previewed as lessons relative to the root of their block.
+func previewTarget(args []string) (target string, lessons []string, err error) {
+	if len(args) == 1 {
+		target = args[0]
+		info, err := os.Stat(target)
+		if err != nil || !info.IsDir() || !isUnitDir(target) {
+			return target, nil, nil
+		}
+		files, err := ioutil.ReadDir(target)
+		if err != nil {
+			return "", nil, err
+		}
+		args = []string{}
+		for _, f := range files {
+			if f.Mode().IsRegular() && strings.HasSuffix(f.Name(), ".md") && !strings.HasPrefix(f.Name(), "__") {
+				args = append(args, filepath.Join(target, f.Name()))
+			}
+		}
+		if len(args) == 0 {
+			return "", nil, fmt.Errorf("There are no lessons to preview in %s", target)
+		}
+	}
+
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return "", nil, fmt.Errorf("Failed to get stats on file. Err: %v", err)
+		}
+		if info.IsDir() || !strings.HasSuffix(arg, ".md") {
+			return "", nil, fmt.Errorf("Only markdown lessons can be previewed together, %s is not one", arg)
+		}
+	}
+
+	root, err := findBlockRoot(commonDir(args))
+	if err != nil {
+		return "", nil, err
+	}
+	for _, arg := range args {
+		lesson, err := relPath(root, arg)
+		if err != nil {
+			return "", nil, err
+		}
+		lessons = append(lessons, lesson)
+	}
+	return root, uniq(lessons), nil
+}
+
+// isUnitDir reports if dir is a unit of lessons rather than a block. A unit has markdown files
+// directly inside it and is in a block, or has no config, units directory or nested lessons a block
+// config could be created from. Hidden, underscored and ignored directories are not lessons.
+func isUnitDir(dir string) bool {
+	if isBlockRoot(dir) {
+		return false
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	root, err := findBlockRoot(dir)
+	if err != nil {
+		return false
+	}
+	inBlock := isBlockRoot(root)
+	ignore, _ := loadLearnIgnore(root)
+
+	hasLessons := false
+	for _, f := range files {
+		if f.Mode().IsRegular() && strings.HasSuffix(f.Name(), ".md") {
+			hasLessons = true
+		}
+		// the lessons of a unit in a block may keep markdown in subdirectories, such as solutions
+		if inBlock || !f.IsDir() || strings.HasPrefix(f.Name(), ".") || strings.HasPrefix(f.Name(), "_") {
+			continue
+		}
+		sub := filepath.Join(dir, f.Name())
+		if rel, err := relPath(root, sub); err == nil && ignore.ignored(rel, true) {
+			continue
+		}
+		if containsMarkdown(sub) {
+			return false
+		}
+	}
+	return hasLessons
+}
+
+// containsMarkdown reports if there are any markdown files in dir or its subdirectories
+func containsMarkdown(dir string) bool {
+	found := false
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(path, ".md") {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+// findBlockRoot returns the closest directory at or above dir with a config or units directory,
+// which is the root of the block. The search stops at the top of the git repository dir is in, which
+// is the root when there is no block below it. dir itself is returned when there is neither.
+func findBlockRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for current := abs; ; current = filepath.Dir(current) {
+		if isBlockRoot(current) {
+			return relPath(".", current)
+		}
+		// a config or units directory above the repository belongs to something else
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return relPath(".", current)
+		}
+		if filepath.Dir(current) == current {
+			return dir, nil
+		}
+	}
+}
+
+// isBlockRoot reports if dir has a config file or a units directory
+func isBlockRoot(dir string) bool {
+	for _, name := range []string{"config.yaml", "config.yml"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.Mode().IsRegular() {
+			return true
+		}
+	}
+	info, err := os.Stat(filepath.Join(dir, stringOr(UnitsDirectory, "units")))
+	return err == nil && info.IsDir()
+}
+
+// commonDir returns the deepest directory containing all of the files
+func commonDir(files []string) string {
+	dir := filepath.Dir(files[0])
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	for _, f := range files[1:] {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			continue
+		}
+		for {
+			rel, err := filepath.Rel(dir, abs)
+			if err == nil && !outsideDir(rel) {
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir
+}
+
+// relPath returns path relative to base when it is inside base, and the absolute path otherwise
+func relPath(base, path string) (string, error) {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absBase, absPath)
+	if err != nil || outsideDir(rel) {
+		return absPath, nil
+	}
+	return rel, nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func Test_previewTarget(t *testing.T) {
+	block := t.TempDir()
+	for _, path := range []string{"units/01-intro/a.md", "units/01-intro/b.md", "units/01-intro/__draft.md", "units/02-loops/c.md", "units/02-loops/images/c.png"} {
+		path = filepath.Join(block, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# Lesson\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, arg := range []string{block, filepath.Join(block, "units/01-intro/a.md")} {
+		target, lessons, err := previewTarget([]string{arg})
+		if err != nil || target != arg || lessons != nil {
+			t.Errorf("a block or single lesson should be previewed as it is, got %s %q %v", target, lessons, err)
+		}
+	}
+
+	target, lessons, err := previewTarget([]string{filepath.Join(block, "units/01-intro")})
+	sort.Strings(lessons)
+	if err != nil || target != block || !reflect.DeepEqual(lessons, []string{filepath.FromSlash("units/01-intro/a.md"), filepath.FromSlash("units/01-intro/b.md")}) {
+		t.Errorf("a unit should be previewed as its lessons in the block, got %s %q %v", target, lessons, err)
+	}
+
+	target, lessons, err = previewTarget([]string{filepath.Join(block, "units/01-intro/b.md"), filepath.Join(block, "units/02-loops/c.md")})
+	sort.Strings(lessons)
+	if err != nil || target != block || !reflect.DeepEqual(lessons, []string{filepath.FromSlash("units/01-intro/b.md"), filepath.FromSlash("units/02-loops/c.md")}) {
+		t.Errorf("several lessons should be previewed as lessons in the block, got %s %q %v", target, lessons, err)
+	}
+
+	if _, _, err := previewTarget([]string{filepath.Join(block, "units/01-intro/a.md"), filepath.Join(block, "units/02-loops/images/c.png")}); err == nil {
+		t.Errorf("only markdown files should be previewed together")
+	}
+}
+
+func Test_isUnitDir(t *testing.T) {
+	if !isUnitDir("../../fixtures/test-block-no-units-dir/single_unit") {
+		t.Errorf("a directory of lessons should be a unit")
+	}
+	for _, dir := range []string{"../../fixtures/test-block-no-units-dir", "../../fixtures/test-block-no-config", "../../fixtures/test-block-with-config"} {
+		if isUnitDir(dir) {
+			t.Errorf("%s is a block, not a unit", dir)
+		}
+	}
+
+	block := writeBlock(t, map[string]string{
+		"units/01-intro/a.md":                "# Lesson",
+		"units/01-intro/solutions/README.md": "# Solutions",
+	})
+	if !isUnitDir(filepath.Join(block, "units/01-intro")) {
+		t.Errorf("markdown in the subdirectories of a unit in a block should not make it a block")
+	}
+
+	unit := writeBlock(t, map[string]string{
+		".learnignore":  "extras/\n",
+		"a.md":          "# Lesson",
+		"_drafts/b.md":  "# Draft",
+		"extras/c.md":   "# Extra",
+		".github/pr.md": "# Template",
+		"images/a.png":  "png",
+	})
+	if !isUnitDir(unit) {
+		t.Errorf("hidden, underscored and ignored directories should not make a unit a block")
+	}
+}
+
+func Test_findBlockRoot(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"config.yaml", "repo/.git/HEAD", "repo/notes/a.md", "repo/block/units/01-intro/a.md"} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# Lesson\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]string{
+		"repo/block/units/01-intro": "repo/block",
+		"repo/notes":                "repo",
+	}
+	for from, expected := range tests {
+		root, err := findBlockRoot(filepath.Join(dir, from))
+		if err != nil {
+			t.Fatalf("findBlockRoot errored: %v", err)
+		}
+		if abs, _ := filepath.Abs(root); abs != filepath.Join(dir, expected) {
+			t.Errorf("the block root of %s should be %s, got %s", from, expected, abs)
+		}
+	}
+}
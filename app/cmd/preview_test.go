@@ -3,12 +3,17 @@ package cmd
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	yaml "gopkg.in/yaml.v2"
 )
 
 const testMDContent = `## Test links
@@ -39,8 +44,8 @@ Question
 `
 
 func Test_ParseConfigFileForPaths(t *testing.T) {
-	previewFindOrCreateConfig(withNoConfigFixture, false, []string{})
-	p := previewBuilder{target: withNoConfigFixture}
+	autoConfig, _ := previewFindOrCreateConfig(withNoConfigFixture, false, []string{})
+	p := previewBuilder{target: withNoConfigFixture, autoConfig: autoConfig}
 	err := p.parseConfigAndGatherPaths()
 
 	if err != nil || len(p.configYamlPaths) == 0 {
@@ -59,8 +64,6 @@ func Test_compressDirectory(t *testing.T) {
 		t.Errorf("There should be paths parsed from the target")
 	}
 
-	tmpZipFile := "../../fixtures/test-block-auto-config/preview-curriculum.zip"
-
 	var challengePaths []string
 	challengePaths = append(challengePaths, "test-block-auto-config/docker/text.text")
 	challengePaths = append(challengePaths, "test-block-auto-config/sql/database.sql")
@@ -69,14 +72,21 @@ func Test_compressDirectory(t *testing.T) {
 		target:          source,
 		challengePaths:  challengePaths,
 		configYamlPaths: p.configYamlPaths,
+		workspace:       t.TempDir(),
 	}
-	err = previewer.compressDirectory(tmpZipFile)
+	err = previewer.compressDirectory(context.Background())
 	if err != nil {
 		t.Errorf("compressDirectory failed to do its job: %s\n", err)
 	}
 
-	read, _ := zip.OpenReader(tmpZipFile)
-	defer read.Close()
+	archive, err := ioutil.ReadFile(previewer.archivePath)
+	if err != nil || int64(len(archive)) != previewer.archiveSize || filepath.Dir(previewer.archivePath) != previewer.workspace {
+		t.Fatalf("compressDirectory should write an archive of %d bytes to the workspace, got %d bytes at %s %v", previewer.archiveSize, len(archive), previewer.archivePath, err)
+	}
+	read, err := zip.NewReader(bytes.NewReader(archive), previewer.archiveSize)
+	if err != nil {
+		t.Fatalf("the archive could not be read: %s", err)
+	}
 
 	var paths = make(map[string]bool)
 	for _, file := range read.File {
@@ -106,12 +116,44 @@ func Test_compressDirectory(t *testing.T) {
 			t.Errorf("Should of found: %s In zipped dir", path)
 		}
 	}
+}
+
+func Test_compressDirectoryAutoConfig(t *testing.T) {
+	block := writeBlock(t, map[string]string{
+		"units/intro.md":  "# Intro",
+		"autoconfig.yaml": "# left by an older preview",
+	})
+
+	autoConfig, err := previewFindOrCreateConfig(block, false, []string{})
+	if err != nil || autoConfig == nil {
+		t.Fatalf("previewFindOrCreateConfig should generate an autoconfig, got %v", err)
+	}
+	p := previewBuilder{target: block, autoConfig: autoConfig, workspace: t.TempDir()}
+	if err := p.parseConfigAndGatherPaths(); err != nil {
+		t.Fatalf("parseConfigAndGatherPaths errored: %s", err)
+	}
+	captureStdout(func() {
+		err = p.compressDirectory(context.Background())
+	})
+	if err != nil {
+		t.Fatalf("compressDirectory errored: %s", err)
+	}
 
-	os.Remove(tmpZipFile)
+	archive, _ := ioutil.ReadFile(p.archivePath)
+	contents := readArchive(t, archive)
+	base := filepath.Base(block)
+	if contents[base+"/autoconfig.yaml"] != string(autoConfig) || contents[base+"/units/intro.md"] != "# Intro" {
+		t.Errorf("the generated autoconfig should be in the archive in place of the old one, got %q", contents)
+	}
+
+	b, _ := ioutil.ReadFile(filepath.Join(block, "autoconfig.yaml"))
+	if string(b) != "# left by an older preview" {
+		t.Errorf("the autoconfig should not be written to the block, it holds %q", b)
+	}
 }
 
 func Test_createNewTarget(t *testing.T) {
-	result, err := createNewTarget("../../fixtures/test-links/nested/test.md", []string{}, []string{"./mrsmall-invert.png", "../mrsmall.png", "../image/nested-small.png", "deeper/deep-small.png"}, []string{})
+	result, err := createNewTarget(tmpSingleFileDir, "../../fixtures/test-links/nested/test.md", []string{}, []string{"./mrsmall-invert.png", "../mrsmall.png", "../image/nested-small.png", "deeper/deep-small.png"}, []string{})
 	if err != nil {
 		t.Errorf("Attempting to createNewTarget errored: %s\n", err)
 	}
@@ -154,7 +196,7 @@ func Test_createNewTargetChallengePathsAndLinks(t *testing.T) {
 	}
 
 	output := captureOutput(func() {
-		createNewTarget("test.md", []string{"/data/some.sql"}, []string{"image/nested-small.png"}, []string{})
+		createNewTarget(tmpSingleFileDir, "test.md", []string{"/data/some.sql"}, []string{"image/nested-small.png"}, []string{})
 		_, err := os.Stat(fmt.Sprintf("single-file-upload/%s", "data/some.sql"))
 		if err == nil {
 			t.Errorf("data/some.sql should not have been copied over")
@@ -228,7 +270,7 @@ func Test_createNewTargetAllAssets(t *testing.T) {
 	}
 
 	output := captureOutput(func() {
-		createNewTarget("test.md", []string{"/tests/test.js", "/setup.js"}, []string{"image/nested-small.png"}, []string{"/path/to/dir"})
+		createNewTarget(tmpSingleFileDir, "test.md", []string{"/tests/test.js", "/setup.js"}, []string{"image/nested-small.png"}, []string{"/path/to/dir"})
 		testFilesExist(t, []string{"/tests/test.js", "/setup.js", "image/nested-small.png", "/path/to/dir"})
 	})
 
@@ -277,7 +319,7 @@ func Test_createNewTargetSingleFileThatIsSQL(t *testing.T) {
 		t.Errorf("Error creating test.md: %s\n", err)
 	}
 	output := captureOutput(func() {
-		createNewTarget("test.md", []string{"/data/some.sql"}, []string{}, []string{})
+		createNewTarget(tmpSingleFileDir, "test.md", []string{"/data/some.sql"}, []string{}, []string{})
 		_, err := os.Stat(fmt.Sprintf("single-file-upload/%s", "data/some.sql"))
 		if err == nil {
 			t.Errorf("data/some.sql should have been copied over and it was not")
@@ -304,7 +346,7 @@ func Test_createNewTargetSingleFile(t *testing.T) {
 	}
 
 	output := captureOutput(func() {
-		result, err := createNewTarget("test.md", []string{}, []string{"./image/nested-small.png", "image/nested-small.png", "../nested-small.png"}, []string{})
+		result, err := createNewTarget(tmpSingleFileDir, "test.md", []string{}, []string{"./image/nested-small.png", "image/nested-small.png", "../nested-small.png"}, []string{})
 		if err != nil {
 			t.Errorf("Attempting to createNewTarget errored: %s\n", err)
 		}
@@ -398,7 +440,7 @@ func Test_createNewTarget_DockerDirectoryIgnore(t *testing.T) {
 	ignoreFile.Write([]byte("docker-compose.yml"))
 
 	output := captureOutput(func() {
-		result, err := createNewTarget("test.md", []string{}, []string{}, []string{"/path/to/dir"})
+		result, err := createNewTarget(tmpSingleFileDir, "test.md", []string{}, []string{}, []string{"/path/to/dir"})
 		if err != nil {
 			t.Errorf("Attempting to createNewTarget errored: %s\n", err)
 		}
@@ -462,7 +504,7 @@ func Test_createNewTarget_DockerDirectoryNestedMd(t *testing.T) {
 	}
 
 	output := captureOutput(func() {
-		result, err := createNewTarget("test.md", []string{}, []string{}, []string{"/path/to/dir"})
+		result, err := createNewTarget(tmpSingleFileDir, "test.md", []string{}, []string{}, []string{"/path/to/dir"})
 		if err != nil {
 			t.Errorf("Attempting to createNewTarget errored: %s\n", err)
 		}
@@ -510,7 +552,7 @@ func Test_createNewTarget_DockerDirectoryDoubleNestedMd(t *testing.T) {
 	}
 
 	output := captureOutput(func() {
-		result, err := createNewTarget("test.md", []string{}, []string{}, []string{"/path/to/dir"})
+		result, err := createNewTarget(tmpSingleFileDir, "test.md", []string{}, []string{}, []string{"/path/to/dir"})
 		if err != nil {
 			t.Errorf("Attempting to createNewTarget errored: %s\n", err)
 		}
@@ -573,3 +615,144 @@ func captureOutput(f func()) string {
 	log.SetOutput(os.Stderr)
 	return buf.String()
 }
+
+func captureStdout(f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return ""
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func Test_createLessonsTarget(t *testing.T) {
+	defer os.RemoveAll(tmpSingleFileDir)
+
+	block := t.TempDir()
+	writeLesson := func(path, content string) {
+		path = filepath.Join(block, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeLesson("units/01-intro/lesson.md", "# Lesson\n\n![alt](./diagram.png)\n![alt](../../images/logo.png)\n")
+	writeLesson("units/01-intro/diagram.png", "png")
+	writeLesson("images/logo.png", "png")
+	writeLesson("units/02-sql/challenge.md", "### !challenge\n\n* type: code-snippet\n* language: sql\n* id: 8c406f4f-6428-498b-be24-6bd0a6c9096c\n* title: sql\n* test_file: /tests/sql.sql\n* docker_directory_path: /docker/sql\n\n##### !question\n\nQuestion\n\n##### !end-question\n\n### !end-challenge\n")
+	writeLesson("tests/sql.sql", "select 1;")
+	writeLesson("docker/sql/Dockerfile", "FROM postgres")
+	writeLesson("units/02-sql/unchanged.md", "# Unchanged\n")
+
+	dockerPaths, challengePaths, linkPaths, err := createLessonsTarget(tmpSingleFileDir, block, []string{filepath.Join("units", "01-intro", "lesson.md"), filepath.Join("units", "02-sql", "challenge.md")})
+	if err != nil {
+		t.Fatalf("Attempting to createLessonsTarget errored: %s\n", err)
+	}
+	if len(dockerPaths) != 1 || len(challengePaths) != 1 || len(linkPaths) != 2 {
+		t.Errorf("createLessonsTarget should return the copied resources, got docker %q challenges %q links %q", dockerPaths, challengePaths, linkPaths)
+	}
+
+	// the lessons and their resources keep their paths in the block, so links do not need rewriting
+	testFilesExist(t, []string{"units/01-intro/lesson.md", "units/01-intro/diagram.png", "images/logo.png", "units/02-sql/challenge.md", "tests/sql.sql", "docker/sql/Dockerfile", "config.yaml"})
+	if _, err := os.Stat("single-file-upload/units/02-sql/unchanged.md"); !os.IsNotExist(err) {
+		t.Errorf("lessons which were not given should not be copied")
+	}
+
+	b, err := ioutil.ReadFile("single-file-upload/config.yaml")
+	if err != nil {
+		t.Fatalf("could not read the generated config.yaml: %s", err)
+	}
+	config := ConfigYaml{}
+	if err = yaml.Unmarshal(b, &config); err != nil {
+		t.Fatalf("could not parse the generated config.yaml: %s", err)
+	}
+	if len(config.Standards) != 2 || config.Standards[0].Title != "Intro" || config.Standards[1].ContentFiles[0].Path != "/units/02-sql/challenge.md" {
+		t.Errorf("the generated config should have a standard for each directory of lessons, got %+v", config)
+	}
+}
+
+func Test_CopyDirectoryContents_DockerIgnoreRules(t *testing.T) {
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "copy")
+	for _, path := range []string{"Dockerfile", "test.sh", "notes.md", "data/big.csv", "data/small.csv", "node_modules/pkg/index.js"} {
+		path = filepath.Join(src, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := CopyDirectoryContents(src, dst, []string{"# scratch files", "*", "", "!data", "data/*.csv", "!data/small.csv"})
+	if err != nil {
+		t.Fatalf("CopyDirectoryContents errored: %s", err)
+	}
+
+	for path, copied := range map[string]bool{
+		"Dockerfile":                true,
+		"test.sh":                   true,
+		"notes.md":                  false,
+		"data/big.csv":              false,
+		"data/small.csv":            true,
+		"node_modules/pkg/index.js": false,
+	} {
+		_, err := os.Stat(filepath.Join(dst, path))
+		if copied && err != nil {
+			t.Errorf("%s should have been copied", path)
+		}
+		if !copied && !os.IsNotExist(err) {
+			t.Errorf("%s should have been ignored", path)
+		}
+	}
+}
+
+func Test_runPreviewWorkspace(t *testing.T) {
+	defer func() { PreviewDryRun = false }()
+	PreviewDryRun = true
+	runTimings = newTimings("preview", "")
+	block := writeBlock(t, map[string]string{
+		"units/01-intro/lesson.md":   "# Lesson\n\n![alt](./diagram.png)\n",
+		"units/01-intro/diagram.png": "png",
+		"units/02-sql/unchanged.md":  "# Unchanged\n",
+	})
+
+	var previewer *previewBuilder
+	var err error
+	out := captureStdout(func() {
+		previewer, err = runPreview(context.Background(), block, []string{filepath.Join("units", "01-intro", "lesson.md")})
+	})
+	if err != nil {
+		t.Fatalf("runPreview errored: %s", err)
+	}
+	if !strings.Contains(out, "  units/01-intro/diagram.png\n") || !strings.Contains(out, "  config.yaml\n") {
+		t.Errorf("the lessons should be previewed from the workspace, got:\n%s", out)
+	}
+	if strings.HasPrefix(previewer.workspace, block) {
+		t.Errorf("the workspace should not be in the block, got %s", previewer.workspace)
+	}
+	if _, err := os.Stat(previewer.workspace); !os.IsNotExist(err) {
+		t.Errorf("the workspace should be removed once the preview is done, got %v", err)
+	}
+	if _, err := os.Stat(tmpSingleFileDir); !os.IsNotExist(err) {
+		t.Errorf("nothing should be written to the working directory")
+	}
+
+	out = captureStdout(func() {
+		previewer, err = runPreview(context.Background(), block, nil)
+	})
+	if err != nil || !strings.Contains(out, "  autoconfig.yaml\n") {
+		t.Errorf("a block without a config should be previewed with a generated autoconfig, got %v and:\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(block, "autoconfig.yaml")); !os.IsNotExist(err) {
+		t.Errorf("the autoconfig should not be written to the block")
+	}
+}
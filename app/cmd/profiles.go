@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// defaultProfileName is the profile kept at the top level of ~/.glearn-config.yaml, as written by
+// earlier versions of the CLI
+const defaultProfileName = "default"
+
+// defaultBaseURL is the Learn used by a profile without a base_url
+const defaultBaseURL = "https://learn-2.galvanize.com"
+
+// ProfileName is the global flag choosing the profile to use, or to change with learn set
+var ProfileName string
+
+// learnProfile is the profile learn.API was created with by setupLearnAPI
+var learnProfile = Profile{Name: defaultProfileName, BaseURL: defaultBaseURL}
+
+// Profile is a Learn environment and the API token used with it. Profiles other than the default
+// are kept under profiles in ~/.glearn-config.yaml:
+//
+//	api_token: <production token>
+//	current_profile: staging
+//	profiles:
+//	  staging:
+//	    base_url: https://learn-staging.example.com
+//	    api_token: <staging token>
+type Profile struct {
+	Name     string
+	BaseURL  string
+	APIToken string
+}
+
+var profilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "Manage the Learn environments in ~/.glearn-config.yaml",
+	Long: `
+Profiles hold the base URL and API token for a Learn environment, such as
+production, staging or a local instance. Add or change one with:
+
+  learn set --profile=staging --base_url=https://learn-staging.example.com --api_token=<token>
+
+The profile used by a command is the first of the --profile flag, the
+LEARN_PROFILE environment variable, the profile chosen with 'learn profiles use',
+or the default profile. LEARN_BASE_URL and LEARN_API_TOKEN still override the base
+URL and API token of any profile.
+	`,
+}
+
+var profilesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the profiles in ~/.glearn-config.yaml",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		printProfiles(os.Stdout, configuredProfiles(), profileName())
+	},
+}
+
+var profilesUseCmd = &cobra.Command{
+	Use:   "use <profile>",
+	Short: "Choose the profile used when no --profile flag or LEARN_PROFILE is given",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := strings.ToLower(args[0])
+		if _, ok := findProfile(name); !ok {
+			fmt.Fprintf(os.Stderr, "No profile named '%s', add it with: learn set --profile=%s --api_token=<token>\n", name, name)
+			os.Exit(1)
+		}
+
+		viper.Set("current_profile", name)
+		err := writeConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "There was an error writing the profile to your config: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Now using profile '%s'\n", name)
+	},
+}
+
+// profileName returns the name of the profile chosen for the command
+func profileName() string {
+	for _, name := range []string{ProfileName, os.Getenv("LEARN_PROFILE"), viper.GetString("current_profile")} {
+		if name != "" {
+			return strings.ToLower(name)
+		}
+	}
+	return defaultProfileName
+}
+
+// currentProfile returns the profile chosen for the command, reporting an error when the profile is
+// missing or has no API token. LEARN_API_TOKEN overrides the token of the profile, so CI can publish
+// without writing a config file.
+func currentProfile() (Profile, error) {
+	name := profileName()
+	profile, ok := findProfile(name)
+	if !ok {
+		return profile, fmt.Errorf("No profile named '%s' in ~/.glearn-config.yaml, add it with: learn set --profile=%s --api_token=<token>", name, name)
+	}
+
+	if alternateURL := os.Getenv("LEARN_BASE_URL"); alternateURL != "" {
+		profile.BaseURL = alternateURL
+	}
+
+	if token := os.Getenv(apiTokenEnv); token != "" {
+		profile.APIToken = token
+	}
+
+	if profile.APIToken == "" {
+		return profile, fmt.Errorf("%s", setTokenMessage(profile))
+	}
+
+	return profile, nil
+}
+
+// setTokenMessage explains how to set the API token of a profile
+func setTokenMessage(p Profile) string {
+	if p.Name == defaultProfileName {
+		return setAPITokenMessage
+	}
+	return fmt.Sprintf("\nPlease log in to profile '%s' with this command: learn login --profile=%s\nOr set its API token with this command: learn set --profile=%s --api_token=<your_api_token>\nYou can get your api token at %s/api_token", p.Name, p.Name, p.Name, p.BaseURL)
+}
+
+// findProfile reads the named profile from the config
+func findProfile(name string) (Profile, bool) {
+	if name == defaultProfileName {
+		return Profile{
+			Name:     defaultProfileName,
+			BaseURL:  stringOr(viper.GetString("base_url"), defaultBaseURL),
+			APIToken: viper.GetString("api_token"),
+		}, true
+	}
+
+	key := "profiles." + name
+	if !viper.IsSet(key) {
+		return Profile{Name: name}, false
+	}
+	return Profile{
+		Name:     name,
+		BaseURL:  stringOr(viper.GetString(key+".base_url"), defaultBaseURL),
+		APIToken: viper.GetString(key + ".api_token"),
+	}, true
+}
+
+// configuredProfiles returns the default profile followed by every named profile in the config
+func configuredProfiles() []Profile {
+	names := []string{}
+	for name := range viper.GetStringMap("profiles") {
+		if name != defaultProfileName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	profiles := []Profile{}
+	for _, name := range append([]string{defaultProfileName}, names...) {
+		if profile, ok := findProfile(name); ok {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// setProfile writes the token and base URL of a profile to the config, where an empty value is left unchanged
+func setProfile(name, apiToken, baseURL string) {
+	prefix := ""
+	if name != defaultProfileName {
+		prefix = "profiles." + name + "."
+	}
+	if apiToken != "" {
+		viper.Set(prefix+"api_token", apiToken)
+	}
+	if baseURL != "" {
+		viper.Set(prefix+"base_url", strings.TrimSuffix(baseURL, "/"))
+	}
+}
+
+// printProfiles writes a table of profiles to w, marking the current one
+func printProfiles(w io.Writer, profiles []Profile, current string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tNAME\tBASE URL\tAPI TOKEN")
+	for _, p := range profiles {
+		marker := ""
+		if p.Name == current {
+			marker = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", marker, p.Name, p.BaseURL, maskToken(p.APIToken))
+	}
+	tw.Flush()
+}
+
+// String describes the profile for command output
+func (p Profile) String() string {
+	return fmt.Sprintf("%s (%s)", p.Name, p.BaseURL)
+}
+
+// maskToken hides all but the last 4 characters of a token
+func maskToken(token string) string {
+	if token == "" {
+		return "not set"
+	}
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", 8) + token[len(token)-4:]
+}
+
+// stringOr returns s, or fallback when s is empty
+func stringOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// resetProfiles clears the config and profile flag before and after a test
+func resetProfiles(t *testing.T) {
+	viper.Reset()
+	ProfileName = ""
+	t.Setenv("LEARN_PROFILE", "")
+	t.Setenv("LEARN_BASE_URL", "")
+	t.Cleanup(func() {
+		viper.Reset()
+		ProfileName = ""
+	})
+}
+
+func Test_profileName(t *testing.T) {
+	resetProfiles(t)
+
+	if name := profileName(); name != defaultProfileName {
+		t.Errorf("profileName should be the default with nothing chosen, got %s", name)
+	}
+
+	viper.Set("current_profile", "staging")
+	if name := profileName(); name != "staging" {
+		t.Errorf("profileName should use current_profile, got %s", name)
+	}
+
+	t.Setenv("LEARN_PROFILE", "Local")
+	if name := profileName(); name != "local" {
+		t.Errorf("profileName should prefer LEARN_PROFILE over current_profile, got %s", name)
+	}
+
+	ProfileName = "production"
+	if name := profileName(); name != "production" {
+		t.Errorf("profileName should prefer the --profile flag, got %s", name)
+	}
+}
+
+func Test_currentProfile(t *testing.T) {
+	resetProfiles(t)
+	viper.Set("api_token", "prod-token")
+	setProfile("staging", "staging-token", "https://staging.example.com/")
+	setProfile("local", "", "http://localhost:3000")
+
+	p, err := currentProfile()
+	if err != nil || p.APIToken != "prod-token" || p.BaseURL != defaultBaseURL {
+		t.Errorf("currentProfile should return the default profile, got %+v and %v", p, err)
+	}
+
+	ProfileName = "staging"
+	p, err = currentProfile()
+	if err != nil || p.APIToken != "staging-token" || p.BaseURL != "https://staging.example.com" {
+		t.Errorf("currentProfile should return the staging profile, got %+v and %v", p, err)
+	}
+
+	t.Setenv("LEARN_BASE_URL", "http://127.0.0.1:8080")
+	p, _ = currentProfile()
+	if p.BaseURL != "http://127.0.0.1:8080" || p.APIToken != "staging-token" {
+		t.Errorf("LEARN_BASE_URL should override the base url of the profile, got %+v", p)
+	}
+
+	ProfileName = "local"
+	_, err = currentProfile()
+	if err == nil || !strings.Contains(err.Error(), "learn set --profile=local --api_token") {
+		t.Errorf("currentProfile should explain how to set the token of the profile, got %v", err)
+	}
+
+	ProfileName = "missing"
+	_, err = currentProfile()
+	if err == nil || !strings.Contains(err.Error(), "No profile named 'missing'") {
+		t.Errorf("currentProfile should report a missing profile, got %v", err)
+	}
+}
+
+func Test_printProfiles(t *testing.T) {
+	resetProfiles(t)
+	viper.Set("api_token", "prod-token-1234")
+	setProfile("staging", "staging-token-5678", "https://staging.example.com")
+	setProfile("local", "", "http://localhost:3000")
+
+	profiles := configuredProfiles()
+	if len(profiles) != 3 || profiles[0].Name != "default" || profiles[1].Name != "local" || profiles[2].Name != "staging" {
+		t.Errorf("configuredProfiles should list the default profile then the others by name, got %+v", profiles)
+	}
+
+	var out bytes.Buffer
+	printProfiles(&out, profiles, "staging")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("printProfiles should print a header and a line per profile, got:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[3], "*") || strings.HasPrefix(lines[1], "*") {
+		t.Errorf("only the current profile should be marked, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "prod-token") || !strings.Contains(out.String(), "********1234") {
+		t.Errorf("tokens should be masked, got:\n%s", out.String())
+	}
+	if !strings.Contains(lines[2], "not set") {
+		t.Errorf("a profile without a token should say so, got:\n%s", out.String())
+	}
+}
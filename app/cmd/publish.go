@@ -1,25 +1,26 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"regexp"
 
-	"github.com/briandowns/spinner"
 	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/gSchool/glearn-cli/git"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-const (
-	branchCommand     = `git rev-parse --abbrev-ref HEAD`
-	pushRemoteCommand = `git remote get-url --push origin`
-)
+// remoteName is the git remote curriculum is pushed to and published from
+const remoteName = "origin"
+
+// gitRepo is the repository the publish command operates on, replaced with a git.Fake in tests
+var gitRepo git.Repo = git.NewNative("")
 
 var publishCmd = &cobra.Command{
 	Use:   "publish",
@@ -33,48 +34,52 @@ new block. If the block already exists, it will update the existing block.
 	`,
 	Args: cobra.MinimumNArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
-		if viper.Get("api_token") == "" || viper.Get("api_token") == nil {
-			fmt.Fprintln(os.Stderr, setAPITokenMessage)
-			os.Exit(1)
+		ctx := cmd.Context()
+		startRun("publish", "")
+
+		if _, err := currentProfile(); err != nil {
+			failRun(err.Error())
 		}
 
-		setupLearnAPI(false)
+		phase := runTimings.begin("setup")
+		setupLearnAPI(ctx, false)
+		phase.end()
+		fmt.Printf("Using Learn profile %s\n", learnProfile)
 
 		if len(args) != 0 {
-			fmt.Fprintln(os.Stderr, "Usage: `learn publish` takes no arguments, merely pushing latest master and releasing a version to Learn. Use the command from inside a block repository.")
-			os.Exit(1)
+			failRun("Usage: `learn publish` takes no arguments, merely pushing latest master and releasing a version to Learn. Use the command from inside a block repository.")
 		}
 
 		// Start benchmarking the total time spent in publish cmd
 		startOfCmd := time.Now()
 
+		phase = runTimings.begin("block")
 		repoPieces, err := remotePieces()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Cannot run git remote detection with command: %s\n%s\n", pushRemoteCommand, err)
-			os.Exit(1)
+			failRun(fmt.Sprintf("Cannot detect the push url of the '%s' git remote\n%s", remoteName, err))
 		}
 		if repoPieces.RepoName == "" {
-			fmt.Fprintln(os.Stderr, "no fetch remote detected")
-			os.Exit(1)
+			failRun("no fetch remote detected")
 		}
+		runTimings.Target = repoPieces.RepoName
 
-		block, err := learn.API.GetBlockByRepoName(repoPieces)
+		block, err := learn.API.GetBlockByRepoName(ctx, repoPieces)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error fetching block from learn: %s\n", err)
-			os.Exit(1)
+			failRun(fmt.Sprintf("Error fetching block from learn: %s", learnError(err)))
 		}
 		if !block.Exists() {
-			block, err = learn.API.CreateBlockByRepoName(repoPieces)
+			block, err = learn.API.CreateBlockByRepoName(ctx, repoPieces)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error creating block from learn: %s\n", err)
-				os.Exit(1)
+				failRun(fmt.Sprintf("Error creating block from learn: %s", learnError(err)))
 			}
 		}
 
+		runResult.BlockID = block.ID
+		phase.end()
+
 		branch, err := currentBranch()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Cannot run git branch detection with bash:", err)
-			os.Exit(1)
+			failRun(fmt.Sprintf("Cannot detect the current git branch: %s", err))
 		}
 
 		if !IgnoreLocal {
@@ -87,66 +92,49 @@ new block. If the block already exists, it will update the existing block.
 		}
 
 		// Detect config file
+		phase = runTimings.begin("config")
 		path, _ := os.Getwd()
 		createdConfig, err := publishFindOrCreateConfig(path + "/")
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s", fmt.Sprintf("failed to find or create a config file for repo: (%s). Err: %v", branch, err))
-			os.Exit(1)
+			failRun(fmt.Sprintf("failed to find or create a config file for repo: (%s). Err: %v", branch, err))
 		}
+		phase.end()
 		fmt.Printf("Publishing block with repo name %s from branch %s\n", repoPieces.RepoName, branch)
 
-		if createdConfig && CiCdEnvironment {
-			fmt.Fprintln(os.Stderr, "\nError: You cannot use autoconfig.yaml from a CI/CD environment.")
-			fmt.Fprintln(os.Stderr, "Please create a config.yaml file and commit it.")
-			os.Exit(1)
-		} else if createdConfig {
-			fmt.Println("Committing autoconfig.yaml to", branch)
-			err = addAutoConfigAndCommit()
-
-			if err != nil && !strings.Contains(err.Error(), fmt.Sprintf("Your branch is up to date with 'origin/%s'.", branch)) {
-				fmt.Fprintf(os.Stderr, "Error committing the autoconfig.yaml to origin remote on branch, run 'git rm autoconfig.yaml' to remove it from reference then add a new commit: %s", err)
-				os.Exit(1)
-			}
-		}
-
-		// Do not push if in a CI/CD environment
-		if !CiCdEnvironment {
-			fmt.Println("Pushing work to remote origin", branch)
-
-			err = pushToRemote(branch)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\nError pushing to origin remote on branch:\n\n%s", err)
-				os.Exit(1)
-			}
+		phase = runTimings.begin("git push")
+		err = syncPublishBranch(branch, createdConfig)
+		if err != nil {
+			failRun(err.Error())
 		}
+		phase.end()
 
 		// Start benchmark for creating master release & building on learn
 		startOfMasterReleaseAndBuild := time.Now()
 
 		// Start a processing spinner that runs until Learn is finished building the preview
+		phase = runTimings.begin("build")
 		fmt.Println("\nBuilding release...")
-		s := spinner.New(spinner.CharSets[32], 100*time.Millisecond)
-		s.Color("green")
-		s.Start()
+		s := startSpinner(32, "green")
 
 		// Create a release on learn, notify user
-		releaseID, err := learn.API.CreateBranchRelease(block.ID, branch)
+		releaseID, err := learn.API.CreateBranchRelease(ctx, block.ID, branch)
 		if err != nil || releaseID == 0 {
-			fmt.Fprintf(os.Stderr, "Release failed. releaseID: %d. Error: %s\n", releaseID, err)
-			os.Exit(1)
+			stopSpinner(s)
+			failRun(fmt.Sprintf("Release failed. releaseID: %d. Error: %s", releaseID, learnError(err)))
 		}
 
-		var attempts uint8 = 30
-		p, err := learn.API.PollForBuildResponse(releaseID, false, "", &attempts)
+		runResult.ReleaseID = releaseID
+
+		p, err := learn.API.PollForBuildResponse(ctx, releaseID, false, "")
 		if err != nil {
-			s.Stop()
+			stopSpinner(s)
 
 			if p != nil && p.Errors != "" {
-				fmt.Fprintf(os.Stderr, "Release failed: %s\n", p.Errors)
-				os.Exit(1)
+				failRun(fmt.Sprintf("Release failed: %s", p.Errors))
 			}
 
 			if p != nil && len(p.SyncWarnings) > 0 {
+				runResult.Warnings = p.SyncWarnings
 				fmt.Fprintf(os.Stderr, "Release warnings:")
 
 				for _, sw := range p.SyncWarnings {
@@ -154,18 +142,18 @@ new block. If the block already exists, it will update the existing block.
 				}
 			}
 
-			block, err := learn.API.GetBlockByRepoName(repoPieces)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Release failed. Error fetching block from learn: %s\n", err)
-				os.Exit(1)
+			block, blockErr := learn.API.GetBlockByRepoName(ctx, repoPieces)
+			if blockErr != nil {
+				failRun(fmt.Sprintf("Release failed. Error fetching block from learn: %s", learnError(blockErr)))
 			}
 			if len(block.SyncErrors) > 0 {
+				runResult.SyncErrors = block.SyncErrors
 				fmt.Fprintln(os.Stderr, "Release failed. Errors on block:")
 				for _, e := range block.SyncErrors {
 					fmt.Fprintln(os.Stderr, e)
 				}
 			}
-			os.Exit(1)
+			failRun(fmt.Sprintf("Release failed. Error: %s", learnError(err)))
 		}
 
 		// Add benchmark in milliseconds for compressDirectory
@@ -175,10 +163,13 @@ new block. If the block already exists, it will update the existing block.
 			CmdName:               "publish",
 		}
 
-		s.Stop()
+		stopSpinner(s)
+		phase.end()
 
 		blockUrl := fmt.Sprintf("%s/blocks/%d?branch_name=%s", learn.API.BaseURL(), block.ID, url.QueryEscape(branch))
 		fmt.Printf("Block released! %s\n", blockUrl)
+		runResult.BlockURL = blockUrl
+		runResult.Warnings = p.SyncWarnings
 
 		if len(p.SyncWarnings) > 0 {
 			fmt.Println("\nWarnings on new release:")
@@ -187,18 +178,41 @@ new block. If the block already exists, it will update the existing block.
 			}
 		}
 
-		err = learn.API.SendMetadataToLearn(&learn.CLIBenchmarkPayload{
-			CLIBenchmark: bench,
-		})
-		if err != nil {
-			learn.API.NotifySlack(err)
-			os.Exit(1)
-		}
+		sendBenchmark(ctx, bench)
+		finishRun()
 	},
 }
 
 func currentBranch() (string, error) {
-	return runBashCommand(branchCommand)
+	return gitRepo.CurrentBranch()
+}
+
+// syncPublishBranch commits a newly created autoconfig.yaml and pushes the branch to the remote so
+// Learn can release it. Nothing is committed or pushed in a CI/CD environment, where an autoconfig
+// cannot be used.
+func syncPublishBranch(branch string, createdConfig bool) error {
+	if createdConfig && CiCdEnvironment {
+		return errors.New("\nError: You cannot use autoconfig.yaml from a CI/CD environment.\nPlease create a config.yaml file and commit it.")
+	} else if createdConfig {
+		fmt.Println("Committing autoconfig.yaml to", branch)
+		err := addAutoConfigAndCommit()
+		if err != nil {
+			return fmt.Errorf("Error committing the autoconfig.yaml to %s remote on branch, run 'git rm autoconfig.yaml' to remove it from reference then add a new commit: %s", remoteName, err)
+		}
+	}
+
+	// Do not push if in a CI/CD environment
+	if CiCdEnvironment {
+		return nil
+	}
+
+	fmt.Println("Pushing work to remote", remoteName, branch)
+	err := pushToRemote(branch)
+	if err != nil {
+		return fmt.Errorf("\nError pushing to %s remote on branch:\n\n%s", remoteName, err)
+	}
+
+	return nil
 }
 
 var hostedGitRe = regexp.MustCompile(`^(:?(\w+):\/\/\/?)?(?:(~?\w+)@)?([\w\d\.\-_]+)(:?:([\d]+))?(?::)?\/*(.*)\/([\w\d\.\-_]+)(?:\.git)\/?$`)
@@ -253,7 +267,7 @@ func orgAndRepoFromRegex(long, shortEnd string) (string, string) {
 
 func remotePieces() (learn.RepoPieces, error) {
 	var repoPieces learn.RepoPieces
-	s, err := runBashCommand(pushRemoteCommand)
+	s, err := gitRepo.RemoteURL(remoteName)
 	if err != nil {
 		return repoPieces, err
 	}
@@ -262,73 +276,46 @@ func remotePieces() (learn.RepoPieces, error) {
 }
 
 func pushToRemote(branch string) error {
-	out, err := exec.Command("bash", "-c", fmt.Sprintf("git push origin %s", branch)).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("%s", out)
-	}
-
-	return nil
+	return gitRepo.Push(remoteName, branch)
 }
 
+// addAutoConfigAndCommit stages and commits the autoconfig.yaml at the root of the repo. It is not
+// an error when the autoconfig.yaml is unchanged and there is nothing to commit.
 func addAutoConfigAndCommit() error {
-	top, _ := GitTopLevelDir()
-	addCmd := "git add " + strings.TrimSpace(top) + "/autoconfig.yaml"
-	out, err := exec.Command("bash", "-c", addCmd).CombinedOutput()
+	top, err := gitRepo.TopLevelDir()
 	if err != nil {
-		return fmt.Errorf("%s", out)
+		return err
 	}
-	out, err = exec.Command("bash", "-c", "git commit -m \"learn cli tool publish command: adding autoconfig.yaml\"").CombinedOutput()
+	err = gitRepo.Add(filepath.Join(top, "autoconfig.yaml"))
 	if err != nil {
-		return fmt.Errorf("%s", out)
+		return err
 	}
-
-	return nil
-}
-
-func runBashCommand(command string) (string, error) {
-	out, err := exec.Command("bash", "-c", command).CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("%s- %s", out, err)
+	err = gitRepo.Commit("learn cli tool publish command: adding autoconfig.yaml")
+	if err != nil && !errors.Is(err, git.ErrNothingToCommit) {
+		return err
 	}
 
-	return strings.TrimSpace(string(out)), nil
+	return nil
 }
 
+// notCurrentWithRemote reports if the branch has local changes, or commits which differ from the remote.
+// Failures to inspect the repository are not reported as differences.
 func notCurrentWithRemote(branch string) bool {
-	out, err := runBashCommand("git status")
+	dirty, err := gitRepo.IsDirty()
 	if err != nil {
 		return false
 	}
+	if dirty {
+		return true
+	}
 
-	if strings.Contains(out, "Changes not staged for commit:") || strings.Contains(out, "Changes to be committed:") {
+	ahead, behind, err := gitRepo.AheadBehind(remoteName, branch)
+	if errors.Is(err, git.ErrNoRemoteBranch) {
 		return true
 	}
-	// look up the remote branches and their push state
-	remoteOut, err := runBashCommand("git remote show origin")
 	if err != nil {
 		return false
 	}
-	// Get to the section which defines local refs configured for git push
-	// read the lines until we find one which starts with the branch name
-	// If it contains (up to date) then we would be in the clear to publish
-	var afterPushRefs bool
-	for _, line := range strings.Split(remoteOut, "\n") {
-		if afterPushRefs {
-			trimLine := strings.TrimSpace(line)
-			// Lines we are concerned with look like this:
-			//   main        pushes to main        (up to date)
-			if strings.HasPrefix(trimLine, branch+" ") { // branch names can't have whitespace, and the name now starts and ends in whitespace
-				if strings.Contains(trimLine, "(up to date)") {
-					return false
-				} else {
-					return true
-				}
-			}
-		}
-		if strings.Contains(line, "configured for 'git push'") && !afterPushRefs {
-			afterPushRefs = true
-		}
-	}
 
-	return true
+	return ahead > 0 || behind > 0
 }
@@ -1,8 +1,11 @@
 package cmd
 
 import (
-	"github.com/gSchool/glearn-cli/api/learn"
+	"errors"
 	"testing"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/gSchool/glearn-cli/git"
 )
 
 func Test_parseHostedGit_many(t *testing.T) {
@@ -117,3 +120,93 @@ func Test_parseHostedGit_many(t *testing.T) {
 		}
 	}
 }
+
+func Test_remotePieces(t *testing.T) {
+	gitRepo = &git.Fake{Remotes: map[string]string{"origin": "git@github.com:gSchool/blocks-test.git"}}
+
+	pieces, err := remotePieces()
+	if err != nil {
+		t.Errorf("remotePieces errored: %s\n", err)
+	}
+	if pieces.Origin != "github.com" || pieces.Org != "gSchool" || pieces.RepoName != "blocks-test" {
+		t.Errorf("remotePieces parsed the origin remote incorrectly: %+v\n", pieces)
+	}
+
+	gitRepo = &git.Fake{}
+	if _, err = remotePieces(); err == nil {
+		t.Errorf("remotePieces should error when there is no origin remote")
+	}
+}
+
+func Test_notCurrentWithRemote(t *testing.T) {
+	cases := []struct {
+		name string
+		repo *git.Fake
+		want bool
+	}{
+		{"up to date", &git.Fake{}, false},
+		{"dirty", &git.Fake{Dirty: true}, true},
+		{"ahead", &git.Fake{Ahead: 2}, true},
+		{"behind", &git.Fake{Behind: 1}, true},
+		{"never pushed", &git.Fake{NoRemoteBranch: true}, true},
+		{"status failure", &git.Fake{Errs: map[string]error{"IsDirty": errors.New("boom")}}, false},
+	}
+
+	for _, c := range cases {
+		gitRepo = c.repo
+		if got := notCurrentWithRemote("main"); got != c.want {
+			t.Errorf("%s: notCurrentWithRemote should be %v but was %v\n", c.name, c.want, got)
+		}
+	}
+}
+
+func Test_syncPublishBranch(t *testing.T) {
+	defer func() { CiCdEnvironment = false }()
+
+	fake := &git.Fake{TopLevel: "/repo"}
+	gitRepo = fake
+	captureStdout(func() {
+		if err := syncPublishBranch("main", true); err != nil {
+			t.Errorf("syncPublishBranch errored: %s\n", err)
+		}
+	})
+	if len(fake.Added) != 1 || fake.Added[0] != "/repo/autoconfig.yaml" {
+		t.Errorf("autoconfig.yaml at the top level should have been added, added: %v\n", fake.Added)
+	}
+	if len(fake.Commits) != 1 {
+		t.Errorf("autoconfig.yaml should have been committed once, commits: %v\n", fake.Commits)
+	}
+	if len(fake.Pushes) != 1 || fake.Pushes[0] != "origin/main" {
+		t.Errorf("main should have been pushed to origin, pushes: %v\n", fake.Pushes)
+	}
+
+	// an unchanged autoconfig leaves nothing to commit, which is not an error
+	fake = &git.Fake{TopLevel: "/repo", Errs: map[string]error{"Commit": git.ErrNothingToCommit}}
+	gitRepo = fake
+	captureStdout(func() {
+		if err := syncPublishBranch("main", true); err != nil {
+			t.Errorf("syncPublishBranch should ignore an empty commit but errored: %s\n", err)
+		}
+	})
+
+	fake = &git.Fake{Errs: map[string]error{"Push": errors.New("rejected")}}
+	gitRepo = fake
+	captureStdout(func() {
+		if err := syncPublishBranch("main", false); err == nil {
+			t.Errorf("syncPublishBranch should return push failures")
+		}
+	})
+
+	CiCdEnvironment = true
+	fake = &git.Fake{}
+	gitRepo = fake
+	if err := syncPublishBranch("main", false); err != nil {
+		t.Errorf("syncPublishBranch errored in CI/CD: %s\n", err)
+	}
+	if len(fake.Pushes) != 0 {
+		t.Errorf("nothing should be pushed in a CI/CD environment, pushes: %v\n", fake.Pushes)
+	}
+	if err := syncPublishBranch("main", true); err == nil {
+		t.Errorf("an autoconfig cannot be used in a CI/CD environment")
+	}
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/spf13/cobra"
+)
+
+// ReleasesLimit is the flag for the number of releases listed by the releases list command
+var ReleasesLimit int
+
+// ReleaseWait is the flag for the releases status command to poll until the release finishes building
+var ReleaseWait bool
+
+var releasesCmd = &cobra.Command{
+	Use:   "releases",
+	Short: "Check on releases of the current block repository",
+	Long: `
+Releases are created on Learn by 'learn publish'. These commands report on them
+after the publish command has exited, including builds that outlived polling.
+	`,
+}
+
+var releasesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the releases of the block for the current repository",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		if _, err := currentProfile(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		setupLearnAPI(ctx, false)
+
+		block, err := currentBlock(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, learnError(err))
+			os.Exit(1)
+		}
+
+		releases, err := learn.API.GetBlockReleases(ctx, block.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching releases from learn: %s\n", learnError(err))
+			os.Exit(1)
+		}
+		if ReleasesLimit > 0 && len(releases) > ReleasesLimit {
+			releases = releases[:ReleasesLimit]
+		}
+
+		fmt.Printf("Releases for block %d (%s):\n\n", block.ID, block.RepoName)
+		printReleases(os.Stdout, releases)
+	},
+}
+
+var releasesStatusCmd = &cobra.Command{
+	Use:   "status <release-id>",
+	Short: "Show the build status of a release",
+	Long: `
+Shows the status of a release along with any errors or warnings from building it.
+When the release belongs to the block of the current repository, the block's sync
+errors are shown as well. Use --wait to poll until the release finishes building.
+	`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		releaseID, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Release id must be a number, got '%s'\n", args[0])
+			os.Exit(1)
+		}
+
+		if _, err := currentProfile(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		setupLearnAPI(ctx, false)
+
+		var p *learn.PreviewResponse
+		if ReleaseWait {
+			p, err = learn.API.PollForBuildResponse(ctx, releaseID, false, "")
+		} else {
+			p, err = learn.API.GetReleaseStatus(ctx, releaseID, false, "")
+		}
+		// a status received before polling gave up is still shown
+		if p == nil {
+			fmt.Fprintf(os.Stderr, "Error fetching release %d from learn: %s\n", releaseID, learnError(err))
+			os.Exit(1)
+		}
+
+		failed := printReleaseStatus(os.Stdout, releaseID, p, releaseBlockSyncErrors(ctx, releaseID))
+		if err != nil || failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// currentBlock fetches the Learn block for the origin remote of the current repository
+func currentBlock(ctx context.Context) (learn.Block, error) {
+	repoPieces, err := remotePieces()
+	if err != nil {
+		return learn.Block{}, fmt.Errorf("Cannot detect the push url of the '%s' git remote\n%s", remoteName, err)
+	}
+	if repoPieces.RepoName == "" {
+		return learn.Block{}, fmt.Errorf("no fetch remote detected")
+	}
+
+	block, err := learn.API.GetBlockByRepoName(ctx, repoPieces)
+	if err != nil {
+		return learn.Block{}, fmt.Errorf("Error fetching block from learn: %w", err)
+	}
+	if !block.Exists() {
+		return learn.Block{}, fmt.Errorf("No block found on Learn for %s/%s, run 'learn publish' to create it", repoPieces.Org, repoPieces.RepoName)
+	}
+
+	return block, nil
+}
+
+// releaseBlockSyncErrors returns the sync errors of the current repository's block when the release
+// belongs to it. Nothing is returned for a release of any other block, or when the block or its
+// releases cannot be fetched.
+func releaseBlockSyncErrors(ctx context.Context, releaseID int) []string {
+	block, err := currentBlock(ctx)
+	if err != nil {
+		return nil
+	}
+
+	releases, err := learn.API.GetBlockReleases(ctx, block.ID)
+	if err != nil {
+		return nil
+	}
+	for _, r := range releases {
+		if r.ID == releaseID {
+			return block.SyncErrors
+		}
+	}
+	return nil
+}
+
+// printReleases writes a table of releases to w, with any warnings listed beneath each release
+func printReleases(w io.Writer, releases []learn.Release) {
+	if len(releases) == 0 {
+		fmt.Fprintln(w, "No releases found")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tBRANCH\tSTATUS\tCREATED\tWARNINGS")
+	for _, r := range releases {
+		created := ""
+		if !r.CreatedAt.IsZero() {
+			created = r.CreatedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\n", r.ID, r.BranchName, r.Status, created, len(r.SyncWarnings))
+		for _, warning := range r.SyncWarnings {
+			fmt.Fprintf(tw, "\t  %s\t\t\t\n", warning)
+		}
+	}
+	tw.Flush()
+}
+
+// printReleaseStatus writes the status of a release to w and reports if the release failed
+func printReleaseStatus(w io.Writer, releaseID int, p *learn.PreviewResponse, syncErrors []string) (failed bool) {
+	fmt.Fprintf(w, "Release %d: %s\n", releaseID, p.Status)
+	if p.PreviewURL != "" {
+		fmt.Fprintf(w, "URL: %s\n", p.PreviewURL)
+	}
+	if p.IsBuilding() {
+		fmt.Fprintf(w, "Release %d is still building, check on it again with: learn releases status %d --wait\n", releaseID, releaseID)
+	}
+
+	if p.Status == "failed" {
+		failed = true
+	}
+	if p.Errors != "" {
+		failed = true
+		fmt.Fprintf(w, "\nErrors:\n%s\n", p.Errors)
+	}
+	if len(p.SyncWarnings) > 0 {
+		fmt.Fprintln(w, "\nWarnings:")
+		for _, warning := range p.SyncWarnings {
+			fmt.Fprintln(w, warning)
+		}
+	}
+	if len(syncErrors) > 0 {
+		fmt.Fprintln(w, "\nErrors on block:")
+		for _, e := range syncErrors {
+			fmt.Fprintln(w, e)
+		}
+	}
+
+	return failed
+}
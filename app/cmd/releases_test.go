@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gSchool/glearn-cli/api"
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/gSchool/glearn-cli/git"
+	"github.com/spf13/viper"
+)
+
+func Test_printReleases(t *testing.T) {
+	var out bytes.Buffer
+	printReleases(&out, []learn.Release{
+		{ID: 12, BranchName: "main", Status: "success", CreatedAt: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), SyncWarnings: []string{"missing description"}},
+		{ID: 11, BranchName: "fix", Status: "failed"},
+	})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a header, two releases and a warning line, got:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[0], "WARNINGS") {
+		t.Errorf("first line should be the table header, was '%s'", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "12") || !strings.Contains(lines[1], "main") || !strings.Contains(lines[1], "success") {
+		t.Errorf("second line should describe release 12, was '%s'", lines[1])
+	}
+	if !strings.Contains(lines[2], "missing description") {
+		t.Errorf("warnings should be listed beneath their release, was '%s'", lines[2])
+	}
+
+	out.Reset()
+	printReleases(&out, nil)
+	if !strings.Contains(out.String(), "No releases found") {
+		t.Errorf("an empty list should say no releases were found, was '%s'", out.String())
+	}
+}
+
+func Test_printReleaseStatus(t *testing.T) {
+	var out bytes.Buffer
+	failed := printReleaseStatus(&out, 3, &learn.PreviewResponse{Status: "success", SyncWarnings: []string{"odd heading"}}, nil)
+	if failed {
+		t.Errorf("a successful release should not be reported as failed")
+	}
+	if !strings.Contains(out.String(), "Release 3: success") || !strings.Contains(out.String(), "odd heading") {
+		t.Errorf("status and warnings should be printed, was:\n%s", out.String())
+	}
+
+	out.Reset()
+	failed = printReleaseStatus(&out, 4, &learn.PreviewResponse{Status: "failed", Errors: "bad config"}, []string{"unit missing"})
+	if !failed {
+		t.Errorf("a release with errors should be reported as failed")
+	}
+	if !strings.Contains(out.String(), "bad config") || !strings.Contains(out.String(), "unit missing") {
+		t.Errorf("release errors and block sync errors should be printed, was:\n%s", out.String())
+	}
+
+	out.Reset()
+	if !printReleaseStatus(&out, 5, &learn.PreviewResponse{Status: "failed"}, nil) {
+		t.Errorf("a release with a failed status should be reported as failed without any errors")
+	}
+}
+
+func Test_printReleaseStatus_stillBuilding(t *testing.T) {
+	viper.Set("api_token", "apiToken")
+	mockClient := api.MockResponse(`{"status":"processing","release_id":6}`)
+	learn.API, _ = learn.NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+	learn.API.PollInterval = time.Millisecond
+	learn.API.PollTimeout = 0
+
+	p, err := learn.API.PollForBuildResponse(context.Background(), 6, false, "")
+	if err != learn.ErrPollTimeout || p == nil {
+		t.Fatalf("polling should time out with the last status, got %+v and %v", p, err)
+	}
+
+	var out bytes.Buffer
+	if printReleaseStatus(&out, 6, p, nil) {
+		t.Errorf("a release still building should not be reported as failed")
+	}
+	if !strings.Contains(out.String(), "Release 6: processing") || !strings.Contains(out.String(), "still building") {
+		t.Errorf("a release still building when polling times out should say so, was:\n%s", out.String())
+	}
+}
+
+func Test_releaseBlockSyncErrors(t *testing.T) {
+	viper.Set("api_token", "apiToken")
+	gitRepo = &git.Fake{Remotes: map[string]string{"origin": "git@github.com:gSchool/blocks-test.git"}}
+	block := `{"blocks":[{"id":4,"repo_name":"blocks-test","sync_errors":["unit missing"]}]}`
+	releases := `{"releases":[{"id":12},{"id":11}]}`
+
+	mockClient := api.MockResponses(credentialsResponseBody, block, releases)
+	learn.API, _ = learn.NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+	if errs := releaseBlockSyncErrors(context.Background(), 11); len(errs) != 1 || errs[0] != "unit missing" {
+		t.Errorf("sync errors should be returned for a release of the current block, got %v", errs)
+	}
+
+	mockClient = api.MockResponses(credentialsResponseBody, block, releases)
+	learn.API, _ = learn.NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+	if errs := releaseBlockSyncErrors(context.Background(), 99); len(errs) != 0 {
+		t.Errorf("no sync errors should be returned for a release of another block, got %v", errs)
+	}
+}
+
+func Test_currentBlock(t *testing.T) {
+	viper.Set("api_token", "apiToken")
+	mockClient := api.MockResponses(credentialsResponseBody, `{"blocks":[]}`)
+	learn.API, _ = learn.NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+	gitRepo = &git.Fake{Remotes: map[string]string{"origin": "git@github.com:gSchool/blocks-test.git"}}
+
+	if _, err := currentBlock(context.Background()); err == nil || !strings.Contains(err.Error(), "learn publish") {
+		t.Errorf("a repo without a block should suggest publishing, err: %v", err)
+	}
+
+	mockClient = api.MockResponses(credentialsResponseBody, `{"blocks":[{"id":4,"repo_name":"blocks-test"}]}`)
+	learn.API, _ = learn.NewAPI(context.Background(), "https://example.com", "apiToken", mockClient, false)
+	block, err := currentBlock(context.Background())
+	if err != nil || block.ID != 4 {
+		t.Errorf("currentBlock should return block 4, got %+v err %v", block, err)
+	}
+}
@@ -1,24 +1,28 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"os/user"
-	"time"
+	"syscall"
 
-	"github.com/Masterminds/semver"
-	"github.com/gSchool/glearn-cli/api/github"
+	"github.com/gSchool/glearn-cli/api"
 	"github.com/gSchool/glearn-cli/api/learn"
 	"github.com/gSchool/glearn-cli/app/cmd/markdown"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 const setAPITokenMessage = `
-Please set your API token with this command: learn set --api_token=<your_api_token>
+Please log in with this command: learn login
+Or set your API token with this command: learn set --api_token=<your_api_token>
 You can get your api token at https://learn-2.galvanize.com/api_token`
 
 // currentReleaseVersion is used to print the version the user currently has downloaded
@@ -48,6 +52,9 @@ Learn more by running 'learn walkthrough' to create sample materials, or visit
 
 		return nil
 	},
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return setupOutput()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Unknown command. Try `learn help` for more information")
 	},
@@ -56,6 +63,9 @@ Learn more by running 'learn walkthrough' to create sample materials, or visit
 // APIToken is an initialized string used for holding it's flag value
 var APIToken string
 
+// BaseURL is the flag for the Learn base URL of a profile
+var BaseURL string
+
 // UnitsDirectory is a flag for preview command that denotes a location for the units
 var UnitsDirectory string
 
@@ -72,6 +82,15 @@ var IgnoreLocal bool
 // Running in a CI environment and should not try to push changes
 var CiCdEnvironment bool
 
+// RecordFile and ReplayFile are the global flags to record requests to, or replay responses from, a cassette file
+var (
+	RecordFile string
+	ReplayFile string
+)
+
+// httpClient makes every request to Learn and every upload, it is set by setupLearnAPI
+var httpClient api.Client = &http.Client{}
+
 func init() {
 	u, err := user.Current()
 	if err != nil {
@@ -83,10 +102,13 @@ func init() {
 	viper.AddConfigPath(u.HomeDir)
 	viper.SetConfigName(".glearn-config")
 
+	configPath = fmt.Sprintf("%s/.glearn-config.yaml", u.HomeDir)
+
 	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok && os.Getenv(apiTokenEnv) != "" {
+			// No config file is needed when the token is in the environment, e.g. in CI
+		} else if ok {
 			// Config file not found. Either user's first time using CLI or they deleted it
-			configPath := fmt.Sprintf("%s/.glearn-config.yaml", u.HomeDir)
 			initialConfig := []byte(`api_token:`)
 
 			// Write a ~/.glearn-config.yaml file with all the needed credential keys to fill in.
@@ -111,55 +133,162 @@ func init() {
 	rootCmd.AddCommand(guideCmd)
 	rootCmd.AddCommand(setCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(courseCmd)
+	courseCmd.AddCommand(coursePublishCmd)
+	courseCmd.AddCommand(courseValidateCmd)
+	rootCmd.AddCommand(releasesCmd)
+	releasesCmd.AddCommand(releasesListCmd)
+	releasesCmd.AddCommand(releasesStatusCmd)
+	rootCmd.AddCommand(blocksCmd)
+	blocksCmd.AddCommand(blocksShowCmd)
+	blocksCmd.AddCommand(blocksFindCmd)
+	rootCmd.AddCommand(profilesCmd)
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(whoamiCmd)
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(telemetryCmd)
+	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(lintCmd)
+	telemetryCmd.AddCommand(telemetryShowCmd)
+	profilesCmd.AddCommand(profilesListCmd)
+	profilesCmd.AddCommand(profilesUseCmd)
 
 	// Check for flags set by the user and hydrate their corresponding variables.
+	rootCmd.PersistentFlags().StringVarP(&Output, "output", "", "text", "The output format, text or json. With json, preview and publish write a single result to stdout")
+	rootCmd.PersistentFlags().StringVarP(&ProfileName, "profile", "", "", "The profile in ~/.glearn-config.yaml to use, overriding LEARN_PROFILE")
+	setCmd.Flags().StringVarP(&BaseURL, "base_url", "", "", "The base URL of Learn for the profile")
+	loginCmd.Flags().StringVarP(&BaseURL, "base_url", "", "", "The base URL of Learn for the profile")
+	rootCmd.PersistentFlags().StringVarP(&RecordFile, "record", "", "", "Record every request and response to a cassette file, with tokens and signatures redacted")
+	rootCmd.PersistentFlags().StringVarP(&ReplayFile, "replay", "", "", "Respond to requests from a cassette file made with --record instead of the network")
 	setCmd.Flags().StringVarP(&APIToken, "api_token", "", "", "Your Learn api token")
+	upgradeCmd.Flags().BoolVarP(&UpgradeRollback, "rollback", "", false, "Restore the version replaced by the last upgrade")
+	setCmd.Flags().StringVarP(&Telemetry, "telemetry", "", "", "Turn sending timings and error reports to Learn on or off")
 	previewCmd.Flags().StringVarP(&UnitsDirectory, "units", "u", "", "The directory where your units exist")
 	previewCmd.Flags().BoolVarP(&OpenPreview, "open", "o", false, "Open the preview in the browser")
 	previewCmd.Flags().BoolVarP(&FileOnly, "fileonly", "x", false, "Excludes images when previewing a single file, defaults false")
 	publishCmd.Flags().StringVarP(&UnitsDirectory, "units", "u", "", "The directory where your units exist")
 	publishCmd.Flags().BoolVarP(&IgnoreLocal, "ignore-local", "", false, "Ignore local changes and publish remote only")
 	publishCmd.Flags().BoolVarP(&CiCdEnvironment, "ci-cd", "", false, "Running in a CI/CD environment (cannot use with autoconfig feature)")
+	previewCmd.Flags().BoolVarP(&PreviewCI, "ci", "", false, "Preview the curriculum changed since --base for a pull request, without prompting")
+	previewCmd.Flags().BoolVarP(&PreviewChanged, "changed", "", false, "Preview only the lessons changed since --base, together in one preview")
+	previewCmd.Flags().StringVarP(&PreviewBase, "base", "", "", "The ref changes are found against with --ci or --changed, defaults to the pull request's target branch or origin/master")
+	previewCmd.Flags().StringVarP(&PreviewCommentFile, "comment-file", "", "", "Write the markdown of preview links to this file instead of stdout with --ci")
+	previewCmd.Flags().StringVarP(&PreviewCompression, "compression", "", "fast", "How much to compress the preview archive: none, fast, default or best")
+	previewCmd.Flags().BoolVarP(&PreviewDryRun, "dry-run", "", false, "List the files the preview would include and why others are left out, without uploading")
+	previewCmd.MarkFlagsMutuallyExclusive("ci", "changed")
+	previewCmd.MarkFlagsMutuallyExclusive("ci", "dry-run")
+	previewCmd.Flags().BoolVarP(&ShowTimings, "timings", "", false, "Print how long each phase took and save it to the timings history")
+	publishCmd.Flags().BoolVarP(&ShowTimings, "timings", "", false, "Print how long each phase took and save it to the timings history")
+	coursePublishCmd.Flags().IntVarP(&CourseParallel, "parallel", "p", 4, "The number of repos to publish at once")
+	coursePublishCmd.Flags().StringVarP(&CourseBranch, "branch", "b", "master", "The branch to release for every repo")
+	releasesListCmd.Flags().IntVarP(&ReleasesLimit, "limit", "n", 10, "The number of releases to list, 0 lists all")
+	releasesStatusCmd.Flags().BoolVarP(&ReleaseWait, "wait", "w", false, "Poll until the release has finished building")
+	courseValidateCmd.Flags().BoolVarP(&CourseCheckPublished, "published", "", false, "Confirm with Learn that every repo is a published block")
+	blocksFindCmd.Flags().StringVarP(&BlockOrg, "org", "", "", "The org or group the block repo belongs to")
+	blocksFindCmd.Flags().StringVarP(&BlockRepo, "repo", "", "", "The name of the block repo, including any nested groups")
+	blocksFindCmd.Flags().StringVarP(&BlockOrigin, "origin", "", "github.com", "The host of the block repo")
 }
 
-// Execute runs the learn CLI according to the user's command/subcommand/flags
+// Execute runs the learn CLI according to the user's command/subcommand/flags. The context given to
+// commands is cancelled on an interrupt, or when the terminal is closed, stopping any requests to
+// Learn in progress so commands can clean up before exiting. A second interrupt exits right away.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-func setupLearnAPI(getPresignedPostUrl bool) {
-	client := http.Client{Timeout: 15 * time.Second}
-	baseURL := "https://learn-2.galvanize.com"
-	alternateURL := os.Getenv("LEARN_BASE_URL")
-	if alternateURL != "" {
-		baseURL = alternateURL
+func setupLearnAPI(ctx context.Context, getPresignedPostUrl bool) {
+	client, err := newHTTPClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+	httpClient = client
 
-	api, err := learn.NewAPI(baseURL, &client, getPresignedPostUrl)
+	profile, err := currentProfile()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating API client. Err: %v", err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
-		return
 	}
+	learnProfile = profile
 
-	githubAPI := github.NewAPI(&client)
-	version, err := githubAPI.GetLatestVersion()
+	// Optional overrides of the retry and polling defaults, e.g. retry_attempts: 6 and poll_timeout: 5m
+	if viper.IsSet("retry_attempts") {
+		learn.DefaultRetryPolicy.MaxAttempts = viper.GetInt("retry_attempts")
+	}
+	if viper.IsSet("poll_timeout") {
+		learn.DefaultPollTimeout = viper.GetDuration("poll_timeout")
+	}
+
+	api, err := learn.NewAPI(ctx, profile.BaseURL, profile.APIToken, httpClient, getPresignedPostUrl)
 	if err != nil {
-		fmt.Printf("Something went wrong when fetching latest CLI version: %s\n", err)
-	} else if version != currentReleaseVersion {
-		versionRemote, versionRemoteErr := semver.NewVersion(version)
-		versionInstalled, versionInstalledErr := semver.NewVersion(currentReleaseVersion)
-		if versionRemoteErr != nil {
-			fmt.Printf("Failed to parse the CLI's current version. Err: %v", err)
-		} else if versionInstalledErr != nil {
-			fmt.Printf("Failed to parse the latest CLI release version. Err: %v", err)
-		} else if versionInstalled.LessThan(versionRemote) {
-			fmt.Printf("\nWARNING: There is newer version of the learn tool available.\nLatest: %s\nCurrent: %s\nTo avoid issues, upgrade by following the instructions at this link:\nhttps://github.com/gSchool/glearn-cli/blob/master/upgrade_instructions.md\n\n", version, currentReleaseVersion)
-		}
+		fmt.Fprintln(os.Stderr, learnError(err))
+		os.Exit(1)
+		return
 	}
 
+	checkForUpdate(ctx, os.Stdout)
+
 	learn.API = api
 }
+
+// newHTTPClient creates the client for requests to Learn and uploads. Each attempt of a request to Learn
+// is bounded by the APIClient's RequestTimeout rather than the client. With --record the client writes a
+// cassette, which is left open until the CLI exits so interactions before an early exit are kept.
+func newHTTPClient() (api.Client, error) {
+	switch {
+	case RecordFile != "" && ReplayFile != "":
+		return nil, errors.New("--record and --replay cannot be used together")
+	case RecordFile != "":
+		f, err := os.OpenFile(RecordFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("Could not create cassette '%s'. Err: %v", RecordFile, err)
+		}
+		fmt.Fprintf(os.Stderr, "Recording requests to %s\n", RecordFile)
+		return api.NewRecorder(&http.Client{}, f), nil
+	case ReplayFile != "":
+		return api.LoadReplayer(ReplayFile)
+	}
+	return &http.Client{}, nil
+}
+
+// learnError describes an error from the Learn API along with what the user can do about it. Only
+// a token Learn rejected leads to asking for a new token, network failures are reported as such.
+// When the token came from the config and the CLI is run from a terminal, logging in again is offered.
+func learnError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var apiErr *learn.APIError
+	var netErr net.Error
+	var hint string
+	switch {
+	case errors.Is(err, learn.ErrMissingAPIToken), errors.Is(err, context.Canceled):
+	case errors.As(err, &apiErr) && apiErr.Unauthorized():
+		hint = "Learn did not accept your API token." + setTokenMessage(learnProfile)
+		if interactive() && term.IsTerminal(int(os.Stdin.Fd())) && os.Getenv(apiTokenEnv) == "" {
+			hint = offerLogin(os.Stdin, os.Stderr, learnProfile)
+		}
+	case errors.As(err, &apiErr) && apiErr.Forbidden():
+		hint = "Your Learn account does not have permission for this. Ask an admin of the block or its organization on Learn for access."
+	case errors.As(err, &apiErr) && apiErr.Temporary():
+		hint = "Learn is having trouble right now, please try again in a few minutes."
+	case errors.As(err, &netErr):
+		hint = "Could not reach Learn, check your network connection and try again."
+	}
+
+	if hint == "" {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s\n\n%s", err, hint)
+}
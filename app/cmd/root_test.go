@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/gSchool/glearn-cli/api/learn/learntest"
+	"github.com/spf13/viper"
+)
+
+func Test_learnError(t *testing.T) {
+	tests := []struct {
+		err  error
+		hint string
+	}{
+		{fmt.Errorf("Could not retrieve credentials from Learn: %w", &learn.APIError{StatusCode: 401}), "learn set --api_token"},
+		{&learn.APIError{StatusCode: 403}, "does not have permission"},
+		{&learn.APIError{StatusCode: 502}, "try again in a few minutes"},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, "check your network connection"},
+	}
+	for _, tt := range tests {
+		msg := learnError(tt.err)
+		if !strings.HasPrefix(msg, tt.err.Error()) || !strings.Contains(msg, tt.hint) {
+			t.Errorf("learnError(%v) should contain '%s', got '%s'", tt.err, tt.hint, msg)
+		}
+	}
+
+	for _, err := range []error{&learn.APIError{StatusCode: 404}, context.Canceled, learn.ErrMissingAPIToken} {
+		if msg := learnError(err); msg != err.Error() {
+			t.Errorf("learnError(%v) should have no hint, got '%s'", err, msg)
+		}
+	}
+
+	if strings.Contains(learnError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), "api_token") {
+		t.Errorf("learnError should not ask for a new token on network errors")
+	}
+}
+
+func Test_newHTTPClient_RecordAndReplay(t *testing.T) {
+	s := learntest.NewServer()
+	s.BuildPolls = 0
+	viper.Set("api_token", learntest.Token)
+	cassette := filepath.Join(t.TempDir(), "publish.jsonl")
+	urls := []string{"https://github.com/gSchool/projects.git"}
+
+	publish := func() coursePublishResult {
+		client, err := newHTTPClient()
+		if err != nil {
+			t.Fatalf("newHTTPClient error: %s", err)
+		}
+		learn.API, err = learn.NewAPI(context.Background(), s.URL, learntest.Token, client, false)
+		if err != nil {
+			t.Fatalf("NewAPI error: %s", err)
+		}
+		var results []coursePublishResult
+		captureStdout(func() {
+			results = publishCourseRepos(context.Background(), urls, "master", 1)
+		})
+		return results[0]
+	}
+
+	RecordFile = cassette
+	recorded := publish()
+	RecordFile = ""
+	s.Close()
+
+	ReplayFile = cassette
+	defer func() { ReplayFile = "" }()
+	replayed := publish()
+
+	if recorded.Err != nil || replayed.Err != nil {
+		t.Errorf("publishing should succeed when recorded and replayed, got %v and %v", recorded.Err, replayed.Err)
+	}
+	if recorded.BlockID != replayed.BlockID || recorded.ReleaseID != replayed.ReleaseID || !replayed.Created {
+		t.Errorf("the replay should match the recording, got %+v and %+v", recorded, replayed)
+	}
+
+	b, _ := ioutil.ReadFile(cassette)
+	if strings.Contains(string(b), learntest.Token) {
+		t.Errorf("the cassette should not contain the API token")
+	}
+
+	RecordFile = cassette
+	defer func() { RecordFile = "" }()
+	if _, err := newHTTPClient(); err == nil {
+		t.Errorf("newHTTPClient should not allow both --record and --replay")
+	}
+}
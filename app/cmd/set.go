@@ -14,6 +14,14 @@ var setCmd = &cobra.Command{
 	Long: `
 In order to use learn resources through our CLI you must set your
 credentials inside ~/.glearn-config.yaml
+
+Credentials for other Learn environments are kept in profiles, set with:
+
+  learn set --profile=staging --base_url=https://learn-staging.example.com --api_token=value
+
+Sending timings and error reports to Learn is off unless turned on with:
+
+  learn set --telemetry=on
 	`,
 	Args: cobra.MinimumNArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -22,22 +30,44 @@ credentials inside ~/.glearn-config.yaml
 			os.Exit(1)
 		}
 
-		// If the --api_token=some_value flag was given, set it in viper
-		if APIToken == "" {
+		// Telemetry is a setting for every profile
+		if Telemetry != "" {
+			on, err := parseTelemetry(Telemetry)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if on {
+				viper.Set("telemetry", "on")
+			} else {
+				viper.Set("telemetry", "off")
+			}
+		}
+
+		name := profileName()
+		_, exists := findProfile(name)
+
+		// If the --api_token=some_value flag was given, set it in viper. A new profile needs a token.
+		if APIToken == "" && (BaseURL == "" || !exists) && Telemetry == "" {
 			fmt.Fprintln(os.Stderr, "The set command needs '--api_token' flag.\n\nUse: learn set --api_token=value")
 			os.Exit(1)
-		} else {
-			viper.Set("api_token", APIToken)
 		}
+		setProfile(name, APIToken, BaseURL)
 
 		// Write any changes made above to the config
-		err := viper.WriteConfig()
+		err := writeConfig()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "There was an error writing credentials to your config: %v", err)
 			os.Exit(1)
 			return
 		}
 
-		fmt.Println("Successfully added credentials!")
+		if APIToken == "" && BaseURL == "" {
+			fmt.Printf("Telemetry is now %s\n", viper.GetString("telemetry"))
+		} else if name == defaultProfileName {
+			fmt.Println("Successfully added credentials!")
+		} else {
+			fmt.Printf("Successfully added credentials for profile '%s'!\n", name)
+		}
 	},
 }
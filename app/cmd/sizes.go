@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// projectConfigFile is the name of the optional file at the root of a block configuring the CLI for it
+const projectConfigFile = ".learn.yaml"
+
+// Learn leaves files over these sizes out of previews and releases, unless a block sets its own
+// limits in its .learn.yaml
+const (
+	defaultPreviewLimit int64 = 1000000
+	defaultPublishLimit int64 = 20000000
+)
+
+// ProjectYaml is the shape of a .learn.yaml file
+type ProjectYaml struct {
+	SizeLimits SizeLimitsYaml `yaml:"SizeLimits"`
+}
+
+// SizeLimitsYaml are the largest files, e.g. 5MB or 500000, kept in previews and releases of the block
+type SizeLimitsYaml struct {
+	Preview string `yaml:"Preview,omitempty"`
+	Publish string `yaml:"Publish,omitempty"`
+}
+
+// sizeLimits is the size policy of a block. A nil sizeLimits uses the defaults.
+type sizeLimits struct {
+	Preview int64
+	Publish int64
+}
+
+// oversizedFile is a file over the preview limit, which may also be over the publish limit
+type oversizedFile struct {
+	Path         string
+	Size         int64
+	FailsPublish bool
+}
+
+// loadSizeLimits reads the size limits from the .learn.yaml at the root of a block. A block
+// without one uses the defaults. Learn rejects files over the default publish limit whatever the
+// block sets, so its publish limit can only be lowered.
+func loadSizeLimits(root string) (*sizeLimits, error) {
+	limits := &sizeLimits{Preview: defaultPreviewLimit, Publish: defaultPublishLimit}
+
+	path := filepath.Join(root, projectConfigFile)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return limits, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	project := ProjectYaml{}
+	if err := yaml.UnmarshalStrict(data, &project); err != nil {
+		return nil, fmt.Errorf("Could not parse %s. Err: %v", path, err)
+	}
+	if project.SizeLimits.Preview != "" {
+		if limits.Preview, err = parseSize(project.SizeLimits.Preview); err != nil {
+			return nil, fmt.Errorf("Invalid SizeLimits.Preview in %s: %v", path, err)
+		}
+	}
+	if project.SizeLimits.Publish != "" {
+		if limits.Publish, err = parseSize(project.SizeLimits.Publish); err != nil {
+			return nil, fmt.Errorf("Invalid SizeLimits.Publish in %s: %v", path, err)
+		}
+		if limits.Publish > defaultPublishLimit {
+			return nil, fmt.Errorf("Invalid SizeLimits.Publish in %s: Learn does not accept files over %s, the limit can only be lowered", path, formatBytes(defaultPublishLimit))
+		}
+	}
+	return limits, nil
+}
+
+// parseSize reads a size in bytes, or with a KB, MB or GB suffix, e.g. 1.5MB
+func parseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := 1.0
+	for _, unit := range []struct {
+		suffix string
+		bytes  float64
+	}{{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("'%s' is not a size, such as 500KB or 20MB", size)
+	}
+	return int64(n * multiplier), nil
+}
+
+// preview is the largest file kept in a preview
+func (l *sizeLimits) preview() int64 {
+	if l == nil {
+		return defaultPreviewLimit
+	}
+	return l.Preview
+}
+
+// publish is the largest file kept in a release
+func (l *sizeLimits) publish() int64 {
+	if l == nil {
+		return defaultPublishLimit
+	}
+	return l.Publish
+}
+
+// check returns the file at path as an oversizedFile when it is too large to preview
+func (l *sizeLimits) check(path string, size int64) (oversizedFile, bool) {
+	if size <= l.preview() && size <= l.publish() {
+		return oversizedFile{}, false
+	}
+	return oversizedFile{Path: path, Size: size, FailsPublish: size > l.publish()}, true
+}
+
+// reason explains why a file is left out of a preview
+func (l *sizeLimits) reason(f oversizedFile) string {
+	if f.FailsPublish {
+		return fmt.Sprintf("%s or publish, over %s", tooLargeToPreview, formatBytes(l.publish()))
+	}
+	return fmt.Sprintf("%s, over %s", tooLargeToPreview, formatBytes(l.preview()))
+}
+
+// printOversizedFiles writes a table of files over the size limits to w
+func printOversizedFiles(w io.Writer, files []oversizedFile, limits *sizeLimits) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "FILE\tSIZE\tPUBLISH (LIMIT %s)\n", formatBytes(limits.publish()))
+	for _, f := range files {
+		publish := "ok"
+		if f.FailsPublish {
+			publish = "too large"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Path, formatBytes(f.Size), publish)
+	}
+	tw.Flush()
+}
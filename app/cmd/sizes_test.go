@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_parseSize(t *testing.T) {
+	tests := []struct {
+		size  string
+		bytes int64
+	}{
+		{"500000", 500000},
+		{"500 B", 500},
+		{"750KB", 750000},
+		{"1.5mb", 1500000},
+		{" 2 GB ", 2000000000},
+	}
+	for _, test := range tests {
+		if b, err := parseSize(test.size); err != nil || b != test.bytes {
+			t.Errorf("parseSize(%q) should be %d, got %d %v", test.size, test.bytes, b, err)
+		}
+	}
+	for _, size := range []string{"", "MB", "-1MB", "0", "five"} {
+		if _, err := parseSize(size); err == nil {
+			t.Errorf("parseSize(%q) should error", size)
+		}
+	}
+}
+
+func Test_loadSizeLimits(t *testing.T) {
+	block := t.TempDir()
+	limits, err := loadSizeLimits(block)
+	if err != nil || limits.preview() != defaultPreviewLimit || limits.publish() != defaultPublishLimit {
+		t.Errorf("a block without a %s should use the default limits, got %+v %v", projectConfigFile, limits, err)
+	}
+
+	path := filepath.Join(block, projectConfigFile)
+	if err := os.WriteFile(path, []byte("SizeLimits:\n  Preview: 5MB\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	limits, err = loadSizeLimits(block)
+	if err != nil || limits.preview() != 5000000 || limits.publish() != defaultPublishLimit {
+		t.Errorf("the limits set should replace the defaults, got %+v %v", limits, err)
+	}
+
+	if err := os.WriteFile(path, []byte("SizeLimits:\n  Publish: lots\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSizeLimits(block); err == nil || !strings.Contains(err.Error(), "SizeLimits.Publish") {
+		t.Errorf("an invalid limit should error, got %v", err)
+	}
+	if err := os.WriteFile(path, []byte("SizeLimits:\n  Publish: 50MB\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSizeLimits(block); err == nil || !strings.Contains(err.Error(), "can only be lowered") {
+		t.Errorf("a publish limit over Learn's limit should error, got %v", err)
+	}
+	if err := os.WriteFile(path, []byte("SizeLimit:\n  Publish: 5MB\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSizeLimits(block); err == nil {
+		t.Errorf("a misspelled setting should error")
+	}
+}
+
+func Test_sizeLimitsCheck(t *testing.T) {
+	var limits *sizeLimits
+	if _, over := limits.check("small.png", 1000); over {
+		t.Errorf("a file under the preview limit should not be oversized")
+	}
+	f, over := limits.check("video.mp4", 5000000)
+	if !over || f.FailsPublish || limits.reason(f) != "too large to preview, over 1.0 MB" {
+		t.Errorf("a file over the preview limit should be left out of previews, got %+v %s", f, limits.reason(f))
+	}
+	f, over = limits.check("data.zip", 25000000)
+	if !over || !f.FailsPublish || limits.reason(f) != "too large to preview or publish, over 20.0 MB" {
+		t.Errorf("a file over the publish limit should fail publish, got %+v %s", f, limits.reason(f))
+	}
+
+	var out bytes.Buffer
+	printOversizedFiles(&out, []oversizedFile{{"video.mp4", 5000000, false}, {"data.zip", 25000000, true}}, limits)
+	expected := "FILE       SIZE     PUBLISH (LIMIT 20.0 MB)\nvideo.mp4  5.0 MB   ok\ndata.zip   25.0 MB  too large\n"
+	if out.String() != expected {
+		t.Errorf("printOversizedFiles should print a table, got:\n%s", out.String())
+	}
+}
+
+func Test_archiveExclusionSizeLimits(t *testing.T) {
+	block := writeBlock(t, map[string]string{
+		projectConfigFile:   "SizeLimits:\n  Preview: 1KB\n  Publish: 5KB\n",
+		"units/intro.md":    "# Intro\n\n![diagram](./diagram.png)\n![photo](./photo.png)",
+		"units/diagram.png": strings.Repeat("a", 2000),
+		"units/photo.png":   strings.Repeat("a", 6000),
+	})
+	limits, err := loadSizeLimits(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &previewBuilder{target: block, limits: limits, configYamlPaths: []string{"/units/intro.md"}}
+
+	var out bytes.Buffer
+	if err := p.dryRun(&out); err != nil {
+		t.Fatalf("dryRun errored: %v", err)
+	}
+	for _, line := range []string{
+		"units/diagram.png  too large to preview, over 1.0 KB\n",
+		"units/photo.png    too large to preview or publish, over 5.0 KB\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("the block's size limits should leave out large files, expected %q in:\n%s", line, out.String())
+		}
+	}
+}
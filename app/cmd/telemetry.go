@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// telemetryEnv is the environment variable overriding the telemetry setting, e.g. LEARN_TELEMETRY=off
+const telemetryEnv = "LEARN_TELEMETRY"
+
+// Telemetry is the flag for learn set to turn telemetry on or off
+var Telemetry string
+
+// emailRe matches email addresses in error reports
+var emailRe = regexp.MustCompile(`[\w.+\-]+@[\w\-]+(\.[\w\-]+)+`)
+
+// pathRe matches the directories of absolute file paths in error reports, but not URLs
+var pathRe = regexp.MustCompile(`(^|[\s'"(=])((?:[A-Za-z]:\\|/)(?:[^\s'"():/\\]+[/\\])+)`)
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Show what the CLI reports to Learn when telemetry is on",
+	Long: `
+Telemetry is off unless you turn it on. When on, preview and publish send how
+long they took to Learn, and errors are reported to the Learn team with file
+paths and email addresses removed. Turn it on or off with:
+
+  learn set --telemetry=on
+  learn set --telemetry=off
+
+The LEARN_TELEMETRY environment variable overrides the setting.
+	`,
+}
+
+var telemetryShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print whether telemetry is on and an example of what would be sent",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		showTelemetry(os.Stdout)
+	},
+}
+
+// parseTelemetry reads a telemetry setting of on or off, also accepting true, false, 1 and 0
+func parseTelemetry(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "true", "1", "yes":
+		return true, nil
+	case "off", "false", "0", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("Telemetry must be on or off, got '%s'", value)
+}
+
+// telemetryEnabled reports if telemetry is on, and where that was decided
+func telemetryEnabled() (bool, string) {
+	if value := os.Getenv(telemetryEnv); value != "" {
+		if on, err := parseTelemetry(value); err == nil {
+			return on, telemetryEnv
+		}
+	}
+	if viper.IsSet("telemetry") {
+		if on, err := parseTelemetry(viper.GetString("telemetry")); err == nil {
+			return on, "~/.glearn-config.yaml"
+		}
+	}
+	return false, "the default"
+}
+
+// reportError sends a redacted report of err to Learn when telemetry is on. Errors from the user
+// cancelling the command are not reported, and a failure to report is ignored.
+func reportError(ctx context.Context, err error) {
+	if on, _ := telemetryEnabled(); !on || learn.API == nil || err == nil || ctx.Err() != nil {
+		return
+	}
+	learn.API.NotifySlack(ctx, errorReport(learn.LearnUserId, err))
+}
+
+// sendBenchmark sends the timings of a command to Learn when telemetry is on. A failure to send is
+// ignored, it never changes the outcome of the command.
+func sendBenchmark(ctx context.Context, bench *learn.CLIBenchmark) {
+	if on, _ := telemetryEnabled(); !on || learn.API == nil || ctx.Err() != nil {
+		return
+	}
+	learn.API.SendMetadataToLearn(ctx, &learn.CLIBenchmarkPayload{CLIBenchmark: bench})
+}
+
+// errorReport is the text sent to Learn for an error, identifying the user only by id
+func errorReport(userID int, err error) string {
+	return fmt.Sprintf("UserId: %d\nVersion: %s\n%s", userID, currentReleaseVersion, redact(err.Error()))
+}
+
+// redact removes email addresses and the directories of absolute file paths from s, keeping file names
+func redact(s string) string {
+	s = emailRe.ReplaceAllString(s, "<email>")
+	return pathRe.ReplaceAllString(s, "$1<path>/")
+}
+
+// showTelemetry prints the telemetry setting and examples of what is sent when it is on
+func showTelemetry(w io.Writer) {
+	on, source := telemetryEnabled()
+	state := "off"
+	if on {
+		state = "on"
+	}
+	fmt.Fprintf(w, "Telemetry is %s (from %s)\n\n", state, source)
+
+	bench, _ := json.MarshalIndent(&learn.CLIBenchmarkPayload{CLIBenchmark: &learn.CLIBenchmark{
+		Compression:  120,
+		UploadToS3:   850,
+		LearnBuild:   4200,
+		TotalCmdTime: 5300,
+		CmdName:      "preview",
+	}}, "  ", "  ")
+	profile, _ := findProfile(profileName())
+	fmt.Fprintf(w, "When on, preview and publish send their timings to %s/api/v1/users/learn_cli_metadata:\n\n  %s\n\n", stringOr(profile.BaseURL, defaultBaseURL), bench)
+
+	cwd, _ := os.Getwd()
+	example := fmt.Errorf("Failed to compress provided directory (%s). Err: open %s: permission denied", cwd, filepath.Join(cwd, "README.md"))
+	report := errorReport(learn.LearnUserId, example)
+	fmt.Fprintf(w, "Errors are reported to the Learn team like this, with paths and emails removed:\n\n  %s\n", strings.ReplaceAll(report, "\n", "\n  "))
+
+	if !on {
+		fmt.Fprintln(w, "\nNothing is sent while telemetry is off. Turn it on with: learn set --telemetry=on")
+	}
+}
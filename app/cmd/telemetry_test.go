@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/gSchool/glearn-cli/api/learn/learntest"
+	"github.com/spf13/viper"
+)
+
+func Test_telemetryEnabled(t *testing.T) {
+	resetProfiles(t)
+	t.Setenv(telemetryEnv, "")
+
+	if on, source := telemetryEnabled(); on || source != "the default" {
+		t.Errorf("telemetry should be off by default, got %t from %s", on, source)
+	}
+
+	viper.Set("telemetry", "on")
+	if on, _ := telemetryEnabled(); !on {
+		t.Errorf("telemetry should be on when set in the config")
+	}
+
+	t.Setenv(telemetryEnv, "false")
+	if on, source := telemetryEnabled(); on || source != telemetryEnv {
+		t.Errorf("LEARN_TELEMETRY should override the config, got %t from %s", on, source)
+	}
+
+	if _, err := parseTelemetry("sometimes"); err == nil {
+		t.Errorf("parseTelemetry should only accept on or off")
+	}
+}
+
+func Test_errorReport(t *testing.T) {
+	err := errors.New("Failed to compress provided directory (/Users/jane.doe/curriculum/unit-1). Err: open C:\\Users\\jane\\lesson.md: denied for jane.doe@example.com, see https://learn-2.galvanize.com/blocks/1")
+	report := errorReport(42, err)
+
+	for _, secret := range []string{"jane", "curriculum", "example.com"} {
+		if strings.Contains(report, secret) {
+			t.Errorf("errorReport should redact %s, got:\n%s", secret, report)
+		}
+	}
+	for _, kept := range []string{"UserId: 42", "<path>/unit-1", "<path>/lesson.md", "<email>", "https://learn-2.galvanize.com/blocks/1"} {
+		if !strings.Contains(report, kept) {
+			t.Errorf("errorReport should contain %s, got:\n%s", kept, report)
+		}
+	}
+}
+
+func Test_sendBenchmark(t *testing.T) {
+	resetProfiles(t)
+	s := learntest.NewServer()
+	defer s.Close()
+	var err error
+	learn.API, err = learn.NewAPI(context.Background(), s.URL, learntest.Token, s.Client(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { learn.API = nil }()
+
+	t.Setenv(telemetryEnv, "off")
+	sendBenchmark(context.Background(), &learn.CLIBenchmark{CmdName: "preview"})
+	if len(s.Metadata()) != 0 {
+		t.Errorf("sendBenchmark should send nothing while telemetry is off")
+	}
+
+	t.Setenv(telemetryEnv, "on")
+	sendBenchmark(context.Background(), &learn.CLIBenchmark{CmdName: "preview"})
+	if len(s.Metadata()) != 1 {
+		t.Errorf("sendBenchmark should send the benchmark when telemetry is on")
+	}
+
+	// A failure is ignored rather than ending the command
+	s.FailNext(learntest.RouteMetadata, 400)
+	sendBenchmark(context.Background(), &learn.CLIBenchmark{CmdName: "preview"})
+}
+
+func Test_showTelemetry(t *testing.T) {
+	resetProfiles(t)
+	t.Setenv(telemetryEnv, "")
+
+	var out bytes.Buffer
+	showTelemetry(&out)
+	for _, expected := range []string{"Telemetry is off", "time_to_build_on_learn", "<path>/README.md", "Nothing is sent"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("showTelemetry should print %s, got:\n%s", expected, out.String())
+		}
+	}
+}
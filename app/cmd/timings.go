@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+)
+
+// ShowTimings is the flag to print how long each phase of preview or publish took
+var ShowTimings bool
+
+// runTimings are the timings of the preview or publish being run, finished when it exits
+var runTimings *timings
+
+// timingPhase is how long one phase of a command took, along with what it processed
+type timingPhase struct {
+	Name   string `json:"name"`
+	Millis int64  `json:"ms"`
+	Files  int    `json:"files,omitempty"`
+	Bytes  int64  `json:"bytes,omitempty"`
+	start  time.Time
+	ended  bool
+}
+
+// timings collects the phases of a preview or publish, which are printed and appended to the
+// timings history with the --timings flag
+type timings struct {
+	Command   string         `json:"command"`
+	Target    string         `json:"target,omitempty"`
+	Version   string         `json:"version"`
+	Profile   string         `json:"profile"`
+	StartedAt time.Time      `json:"started_at"`
+	TotalMs   int64          `json:"total_ms"`
+	Succeeded bool           `json:"succeeded"`
+	Phases    []*timingPhase `json:"phases"`
+}
+
+// newTimings starts timing a command run on target
+func newTimings(command, target string) *timings {
+	return &timings{
+		Command:   command,
+		Target:    target,
+		Version:   currentReleaseVersion,
+		StartedAt: time.Now(),
+		Phases:    []*timingPhase{},
+	}
+}
+
+// begin starts a phase, which lasts until end is called on it
+func (t *timings) begin(name string) *timingPhase {
+	phase := &timingPhase{Name: name, start: time.Now()}
+	t.Phases = append(t.Phases, phase)
+	return phase
+}
+
+// end records how long the phase took
+func (p *timingPhase) end() {
+	p.Millis = time.Since(p.start).Milliseconds()
+	p.ended = true
+}
+
+// finish ends the command's timings, and the phase it failed in, printing them and appending them
+// to the history when the --timings flag was given
+func (t *timings) finish(succeeded bool) {
+	for _, p := range t.Phases {
+		if !p.ended {
+			p.end()
+		}
+	}
+	t.Succeeded = succeeded
+	t.Profile = learnProfile.Name
+	t.TotalMs = time.Since(t.StartedAt).Milliseconds()
+	if !ShowTimings {
+		return
+	}
+
+	t.print(os.Stdout)
+	path, err := timingsHistoryPath()
+	if err == nil {
+		err = t.appendTo(path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not save timings to the history. Err: %v\n", err)
+		return
+	}
+	fmt.Printf("Timings saved to %s\n", path)
+}
+
+// print writes a table of the phases to w, with the files and bytes processed and upload throughput
+func (t *timings) print(w io.Writer) {
+	fmt.Fprintf(w, "\nTimings for %s:\n", t.Command)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, p := range t.Phases {
+		detail := ""
+		switch {
+		case p.Files > 0:
+			detail = fmt.Sprintf("%d files, %s", p.Files, formatBytes(p.Bytes))
+		case p.Bytes > 0 && p.Millis > 0:
+			detail = fmt.Sprintf("%s at %s/s", formatBytes(p.Bytes), formatBytes(p.Bytes*1000/p.Millis))
+		case p.Bytes > 0:
+			detail = formatBytes(p.Bytes)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", p.Name, formatMillis(p.Millis), detail)
+	}
+	fmt.Fprintf(tw, "  total\t%s\t\n", formatMillis(t.TotalMs))
+	tw.Flush()
+}
+
+// appendTo adds the timings as a line of JSON to the history file at path
+func (t *timings) appendTo(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(t)
+}
+
+// timingsHistoryPath is the JSONL file in the user's config directory which timings are appended to
+func timingsHistoryPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "glearn", "timings.jsonl"), nil
+}
+
+// formatMillis formats a duration in milliseconds, e.g. 850ms or 12.4s
+func formatMillis(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
+
+// formatBytes formats a size in bytes, e.g. 512 B, 3.2 KB or 4.5 MB
+func formatBytes(b int64) string {
+	const unit = 1000
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_timings_print(t *testing.T) {
+	run := newTimings("preview", "unit-1")
+	run.Phases = []*timingPhase{
+		{Name: "paths", Millis: 12},
+		{Name: "compress", Millis: 1200, Files: 120, Bytes: 4500000},
+		{Name: "upload", Millis: 2000, Bytes: 3000000},
+		{Name: "build", Millis: 12400},
+	}
+	run.TotalMs = 15612
+
+	var out bytes.Buffer
+	run.print(&out)
+	for _, expected := range []string{"Timings for preview", "12ms", "120 files, 4.5 MB", "3.0 MB at 1.5 MB/s", "12.4s", "total", "15.6s"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("print should include %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func Test_timings_appendTo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glearn", "timings.jsonl")
+
+	for _, command := range []string{"preview", "publish"} {
+		run := newTimings(command, "unit-1")
+		run.begin("compress").end()
+		run.begin("upload")
+		run.finish(command == "preview")
+		if err := run.appendTo(path); err != nil {
+			t.Fatalf("appendTo should write the history, got %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var runs []timings
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var run timings
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			t.Fatalf("each line of the history should be JSON, got %v", err)
+		}
+		runs = append(runs, run)
+	}
+	if len(runs) != 2 || runs[0].Command != "preview" || runs[1].Command != "publish" {
+		t.Fatalf("appendTo should add a line per run, got %+v", runs)
+	}
+	if !runs[0].Succeeded || runs[1].Succeeded || len(runs[1].Phases) != 2 || runs[1].Phases[1].Name != "upload" {
+		t.Errorf("the history should keep the outcome and phases of each run, got %+v", runs[1])
+	}
+}
+
+func Test_formatBytes(t *testing.T) {
+	for b, expected := range map[int64]string{512: "512 B", 3200: "3.2 KB", 4500000: "4.5 MB", 2100000000: "2.1 GB"} {
+		if got := formatBytes(b); got != expected {
+			t.Errorf("formatBytes(%d) should be %s, got %s", b, expected, got)
+		}
+	}
+}
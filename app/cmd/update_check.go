@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/gSchool/glearn-cli/api/github"
+	"github.com/spf13/viper"
+)
+
+// noUpdateCheckEnv is the environment variable which turns off checking for a newer version
+const noUpdateCheckEnv = "LEARN_NO_UPDATE_CHECK"
+
+// updateCheckTTL is how long the latest version from GitHub is trusted before checking again
+const updateCheckTTL = 24 * time.Hour
+
+// latestVersion fetches the latest release version, replaced in tests
+var latestVersion = func(ctx context.Context) (string, error) {
+	return github.NewAPI(&http.Client{Timeout: 15 * time.Second}).GetLatestVersion(ctx)
+}
+
+// updateCheck is the result of the last check for a newer version, cached in the user's config directory
+type updateCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// checkForUpdate warns when a newer version of the CLI has been released. GitHub is asked at most
+// once per updateCheckTTL, and a failed check is silently tried again after the TTL. Nothing is
+// checked in CI or with preview --ci, during a replay, or with LEARN_NO_UPDATE_CHECK or
+// no_update_check set.
+func checkForUpdate(ctx context.Context, w io.Writer) {
+	if updateCheckDisabled() {
+		return
+	}
+
+	path, err := updateCheckPath()
+	if err != nil {
+		return
+	}
+
+	check := readUpdateCheck(path)
+	if time.Since(check.CheckedAt) > updateCheckTTL {
+		check.CheckedAt = time.Now()
+		if latest, err := latestVersion(ctx); err == nil {
+			check.Latest = latest
+		}
+		writeUpdateCheck(path, check)
+	}
+
+	if newerVersion(check.Latest, currentReleaseVersion) {
+		fmt.Fprintf(w, "\nWARNING: There is newer version of the learn tool available.\nLatest: %s\nCurrent: %s\nTo avoid issues, upgrade by following the instructions at this link:\nhttps://github.com/gSchool/glearn-cli/blob/master/upgrade_instructions.md\n\n", check.Latest, currentReleaseVersion)
+	}
+}
+
+// updateCheckDisabled reports if checking for a newer version has been turned off
+func updateCheckDisabled() bool {
+	if CiCdEnvironment || PreviewCI || ReplayFile != "" || viper.GetBool("no_update_check") {
+		return true
+	}
+	value := os.Getenv(noUpdateCheckEnv)
+	return value != "" && value != "0" && value != "false"
+}
+
+// newerVersion reports if latest is a higher version than current
+func newerVersion(latest, current string) bool {
+	remote, err := semver.NewVersion(latest)
+	if err != nil {
+		return false
+	}
+	installed, err := semver.NewVersion(current)
+	if err != nil {
+		return false
+	}
+	return installed.LessThan(remote)
+}
+
+// updateCheckPath is the file in the user's config directory the last check is cached in
+func updateCheckPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "glearn", "update-check.json"), nil
+}
+
+// readUpdateCheck reads the cached check, which is empty when there is none
+func readUpdateCheck(path string) updateCheck {
+	var check updateCheck
+	b, err := ioutil.ReadFile(path)
+	if err == nil {
+		json.Unmarshal(b, &check)
+	}
+	return check
+}
+
+// writeUpdateCheck caches the check, ignoring any failure since it is only tried again sooner
+func writeUpdateCheck(path string, check updateCheck) {
+	b, err := json.Marshal(check)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0700) == nil {
+		ioutil.WriteFile(path, b, 0600)
+	}
+}
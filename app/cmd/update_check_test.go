@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeLatestVersion replaces the GitHub check, counting the calls made to it
+func fakeLatestVersion(t *testing.T, version string, err error) *int {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(noUpdateCheckEnv, "")
+	calls := 0
+	previous := latestVersion
+	latestVersion = func(ctx context.Context) (string, error) {
+		calls++
+		return version, err
+	}
+	t.Cleanup(func() { latestVersion = previous })
+	return &calls
+}
+
+func Test_checkForUpdate(t *testing.T) {
+	calls := fakeLatestVersion(t, "v99.0.0", nil)
+
+	var out bytes.Buffer
+	checkForUpdate(context.Background(), &out)
+	checkForUpdate(context.Background(), &out)
+	if *calls != 1 {
+		t.Errorf("checkForUpdate should use the cached version within the TTL, made %d calls", *calls)
+	}
+	if strings.Count(out.String(), "Latest: v99.0.0") != 2 {
+		t.Errorf("checkForUpdate should warn about the newer version each time, got:\n%s", out.String())
+	}
+
+	path, _ := updateCheckPath()
+	writeUpdateCheck(path, updateCheck{CheckedAt: time.Now().Add(-2 * updateCheckTTL), Latest: "v0.0.1"})
+	out.Reset()
+	checkForUpdate(context.Background(), &out)
+	if *calls != 2 || !strings.Contains(out.String(), "v99.0.0") {
+		t.Errorf("checkForUpdate should check again once the cache is stale, made %d calls", *calls)
+	}
+}
+
+func Test_checkForUpdate_Offline(t *testing.T) {
+	calls := fakeLatestVersion(t, "", errors.New("dial tcp: no such host"))
+
+	var out bytes.Buffer
+	checkForUpdate(context.Background(), &out)
+	checkForUpdate(context.Background(), &out)
+	if out.Len() != 0 {
+		t.Errorf("checkForUpdate should print nothing when offline, got:\n%s", out.String())
+	}
+	if *calls != 1 {
+		t.Errorf("checkForUpdate should not try again within the TTL after a failure, made %d calls", *calls)
+	}
+}
+
+func Test_checkForUpdate_Disabled(t *testing.T) {
+	calls := fakeLatestVersion(t, "v99.0.0", nil)
+
+	t.Setenv(noUpdateCheckEnv, "1")
+	var out bytes.Buffer
+	checkForUpdate(context.Background(), &out)
+
+	t.Setenv(noUpdateCheckEnv, "")
+	CiCdEnvironment = true
+	defer func() { CiCdEnvironment = false }()
+	checkForUpdate(context.Background(), &out)
+
+	if *calls != 0 || out.Len() != 0 {
+		t.Errorf("checkForUpdate should be skipped, made %d calls and printed:\n%s", *calls, out.String())
+	}
+}
+
+func Test_newerVersion(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		expected        bool
+	}{
+		{"v0.10.15", "v0.10.14", true},
+		{"v0.10.2", "v0.9.30", true},
+		{"v0.10.14", "v0.10.14", false},
+		{"v0.9.0", "v0.10.14", false},
+		{"", "v0.10.14", false},
+	}
+	for _, tt := range tests {
+		if got := newerVersion(tt.latest, tt.current); got != tt.expected {
+			t.Errorf("newerVersion(%s, %s) should be %t", tt.latest, tt.current, tt.expected)
+		}
+	}
+}
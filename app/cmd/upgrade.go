@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gSchool/glearn-cli/api/github"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// githubAPIEnv is the environment variable overriding the GitHub API releases are found on
+const githubAPIEnv = "LEARN_GITHUB_API_URL"
+
+// upgradeTimeout limits each request made by an upgrade, including downloading the release archive
+const upgradeTimeout = 5 * time.Minute
+
+// checksumsAsset is the release asset listing the sha256 of every archive
+const checksumsAsset = "checksums.txt"
+
+// UpgradeRollback is the flag to restore the version replaced by the last upgrade
+var UpgradeRollback bool
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the learn CLI to the latest release",
+	Long: `
+Downloads the latest release of the learn CLI for your OS and architecture from
+GitHub, verifies its checksum and replaces the learn executable. The replaced
+version is kept next to the executable, and restored with:
+
+  learn upgrade --rollback
+
+If you installed learn with Homebrew, upgrade with brew upgrade learn instead.
+	`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		exe, err := executablePath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not find the learn executable to upgrade. Err: %v\n", err)
+			os.Exit(1)
+		}
+
+		if UpgradeRollback {
+			err = rollbackExecutable(exe)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, upgradeError(err))
+				os.Exit(1)
+			}
+			fmt.Printf("Restored the previous version of %s\n", exe)
+			return
+		}
+
+		if strings.Contains(exe, "/Cellar/") {
+			fmt.Fprintln(os.Stderr, "learn was installed with Homebrew, upgrade it with: brew upgrade learn")
+			os.Exit(1)
+		}
+
+		gh := github.NewAPI(&http.Client{Timeout: upgradeTimeout})
+		gh.BaseURL = githubAPIURL()
+		version, err := upgradeExecutable(cmd.Context(), gh, exe, runtime.GOOS, runtime.GOARCH)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, upgradeError(err))
+			os.Exit(1)
+		}
+		if version == "" {
+			fmt.Printf("learn %s is the latest version\n", currentReleaseVersion)
+			return
+		}
+		fmt.Printf("Upgraded learn from %s to %s\nRun 'learn upgrade --rollback' to go back to %s\n", currentReleaseVersion, version, currentReleaseVersion)
+	},
+}
+
+// githubAPIURL is the GitHub API to find releases on, from LEARN_GITHUB_API_URL or github_api_url in the config
+func githubAPIURL() string {
+	if url := os.Getenv(githubAPIEnv); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return strings.TrimSuffix(stringOr(viper.GetString("github_api_url"), github.DefaultBaseURL), "/")
+}
+
+// executablePath returns the path of the running learn executable, following any symlinks
+func executablePath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// upgradeExecutable replaces exe with the latest release for goos and goarch, returning the version
+// installed. Nothing is installed when the current version is the latest, and the version is empty.
+func upgradeExecutable(ctx context.Context, gh *github.APIClient, exe, goos, goarch string) (string, error) {
+	release, err := gh.GetLatestRelease(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Could not find the latest release. Err: %v", err)
+	}
+	if !newerVersion(release.TagName, currentReleaseVersion) {
+		return "", nil
+	}
+
+	name := releaseAssetName(release.TagName, goos, goarch)
+	archive, ok := release.Asset(name)
+	if !ok {
+		return "", fmt.Errorf("Release %s has no download for %s/%s, expected %s", release.TagName, goos, goarch, name)
+	}
+	checksums, ok := release.Asset(checksumsAsset)
+	if !ok {
+		return "", fmt.Errorf("Release %s has no %s to verify the download with", release.TagName, checksumsAsset)
+	}
+
+	var sums bytes.Buffer
+	if err := gh.Download(ctx, checksums.BrowserDownloadURL, &sums); err != nil {
+		return "", fmt.Errorf("Could not download %s. Err: %v", checksumsAsset, err)
+	}
+	expected, err := findChecksum(&sums, name)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Downloading learn %s...\n", release.TagName)
+	download, err := ioutil.TempFile(filepath.Dir(exe), ".learn-download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(download.Name())
+	defer download.Close()
+
+	hash := sha256.New()
+	if err := gh.Download(ctx, archive.BrowserDownloadURL, io.MultiWriter(download, hash)); err != nil {
+		return "", fmt.Errorf("Could not download %s. Err: %v", name, err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != expected {
+		return "", fmt.Errorf("The checksum of %s does not match %s, nothing was installed", name, checksumsAsset)
+	}
+
+	info, err := download.Stat()
+	if err != nil {
+		return "", err
+	}
+	binary, err := extractExecutable(download, info.Size(), name, goos)
+	if err != nil {
+		return "", fmt.Errorf("Could not extract learn from %s. Err: %v", name, err)
+	}
+	defer binary.Close()
+
+	if err := replaceExecutable(exe, binary); err != nil {
+		return "", err
+	}
+	return release.TagName, nil
+}
+
+// releaseAssetName is the archive goreleaser builds for a platform, e.g. glearn-cli_0.10.14_Darwin_x86_64.tar.gz
+func releaseAssetName(tag, goos, goarch string) string {
+	osNames := map[string]string{"darwin": "Darwin", "linux": "Linux", "windows": "Windows"}
+	archNames := map[string]string{"amd64": "x86_64", "386": "i386", "arm": "armv6"}
+
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("glearn-cli_%s_%s_%s%s", strings.TrimPrefix(tag, "v"), stringOr(osNames[goos], goos), stringOr(archNames[goarch], goarch), ext)
+}
+
+// findChecksum returns the sha256 of name in a checksums.txt, where each line is "<sha256>  <name>"
+func findChecksum(r io.Reader, name string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no checksum for %s, nothing was installed", checksumsAsset, name)
+}
+
+// extractExecutable opens the learn executable in a release archive of size bytes, a zip on Windows
+// and a tar.gz otherwise
+func extractExecutable(archive io.ReaderAt, size int64, name, goos string) (io.ReadCloser, error) {
+	binaryName := "learn"
+	if goos == "windows" {
+		binaryName = "learn.exe"
+	}
+
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(archive, size)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) == binaryName {
+				return f.Open()
+			}
+		}
+		return nil, fmt.Errorf("%s is not in the archive", binaryName)
+	}
+
+	gz, err := gzip.NewReader(io.NewSectionReader(archive, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			gz.Close()
+			return nil, fmt.Errorf("%s is not in the archive", binaryName)
+		}
+		if err != nil {
+			gz.Close()
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == binaryName {
+			return struct {
+				io.Reader
+				io.Closer
+			}{tr, gz}, nil
+		}
+	}
+}
+
+// backupPath is where the version replaced by an upgrade is kept
+func backupPath(exe string) string {
+	return exe + ".old"
+}
+
+// replaceExecutable copies binary next to exe and renames it over exe, so exe is always either the
+// old or the new version. The old version is kept at backupPath for a rollback.
+func replaceExecutable(exe string, binary io.Reader) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(exe), ".learn-upgrade-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, binary)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0111); err != nil {
+		return err
+	}
+
+	backup := backupPath(exe)
+	os.Remove(backup)
+	if err := copyFile(exe, backup); err != nil {
+		return fmt.Errorf("Could not back up %s. Err: %w", exe, err)
+	}
+
+	// A running executable cannot be replaced on Windows, but it can be moved aside
+	if runtime.GOOS == "windows" {
+		os.Remove(exe + ".replaced")
+		if err := os.Rename(exe, exe+".replaced"); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), exe)
+}
+
+// rollbackExecutable restores the version kept by the last upgrade
+func rollbackExecutable(exe string) error {
+	backup := backupPath(exe)
+	if _, err := os.Stat(backup); err != nil {
+		return fmt.Errorf("There is no previous version at %s to roll back to", backup)
+	}
+
+	if runtime.GOOS == "windows" {
+		os.Remove(exe + ".replaced")
+		if err := os.Rename(exe, exe+".replaced"); err != nil {
+			return err
+		}
+	}
+	return os.Rename(backup, exe)
+}
+
+// copyFile copies the file at src to dst with the same permissions
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// upgradeError adds a hint to use sudo when the executable's directory cannot be written to
+func upgradeError(err error) string {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Sprintf("%v\n\nYou do not have permission to replace learn, try again with: sudo learn upgrade", err)
+	}
+	return err.Error()
+}
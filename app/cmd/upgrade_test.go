@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gSchool/glearn-cli/api/github"
+)
+
+// fakeReleases serves a latest release with a linux amd64 archive containing binary, and a checksums.txt
+func fakeReleases(t *testing.T, binary []byte, checksum string) *httptest.Server {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: 5, Typeflag: tar.TypeReg})
+	tw.Write([]byte("hello"))
+	tw.WriteHeader(&tar.Header{Name: "learn", Mode: 0755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+	tw.Write(binary)
+	tw.Close()
+	gz.Close()
+
+	name := "glearn-cli_99.0.0_Linux_x86_64.tar.gz"
+	if checksum == "" {
+		sum := sha256.Sum256(archive.Bytes())
+		checksum = hex.EncodeToString(sum[:])
+	}
+
+	mux := http.NewServeMux()
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	mux.HandleFunc("/repos/gSchool/glearn-cli/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name":"v99.0.0","assets":[{"name":"checksums.txt","browser_download_url":"%[1]s/download/checksums.txt"},{"name":"%[2]s","browser_download_url":"%[1]s/download/%[2]s"}]}`, s.URL, name)
+	})
+	mux.HandleFunc("/download/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "0123abcd  glearn-cli_99.0.0_Darwin_x86_64.tar.gz\n%s  %s\n", checksum, name)
+	})
+	mux.HandleFunc("/download/"+name, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive.Bytes())
+	})
+	return s
+}
+
+// fakeExecutable writes an executable to upgrade in a temporary directory
+func fakeExecutable(t *testing.T) string {
+	exe := filepath.Join(t.TempDir(), "learn")
+	if err := ioutil.WriteFile(exe, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return exe
+}
+
+func Test_upgradeExecutable(t *testing.T) {
+	s := fakeReleases(t, []byte("new binary"), "")
+	exe := fakeExecutable(t)
+	gh := github.NewAPI(s.Client())
+	gh.BaseURL = s.URL
+
+	var version string
+	var err error
+	captureStdout(func() {
+		version, err = upgradeExecutable(context.Background(), gh, exe, "linux", "amd64")
+	})
+	if err != nil || version != "v99.0.0" {
+		t.Fatalf("upgradeExecutable should install v99.0.0, got '%s' and %v", version, err)
+	}
+	if b, _ := ioutil.ReadFile(exe); string(b) != "new binary" {
+		t.Errorf("upgradeExecutable should replace the executable, got %s", b)
+	}
+	if info, _ := os.Stat(exe); info.Mode().Perm()&0111 == 0 {
+		t.Errorf("the new executable should be executable, got %s", info.Mode())
+	}
+	if b, _ := ioutil.ReadFile(backupPath(exe)); string(b) != "old binary" {
+		t.Errorf("upgradeExecutable should keep a backup of the old version, got %s", b)
+	}
+	if files, _ := ioutil.ReadDir(filepath.Dir(exe)); len(files) != 2 {
+		t.Errorf("upgradeExecutable should remove the download, got %d files next to the executable", len(files))
+	}
+
+	if err := rollbackExecutable(exe); err != nil {
+		t.Errorf("rollbackExecutable should succeed, got %v", err)
+	}
+	if b, _ := ioutil.ReadFile(exe); string(b) != "old binary" {
+		t.Errorf("rollbackExecutable should restore the old version, got %s", b)
+	}
+	if err := rollbackExecutable(exe); err == nil {
+		t.Errorf("rollbackExecutable should fail without a backup")
+	}
+}
+
+func Test_upgradeExecutable_BadChecksum(t *testing.T) {
+	s := fakeReleases(t, []byte("tampered binary"), "0000")
+	exe := fakeExecutable(t)
+	gh := github.NewAPI(s.Client())
+	gh.BaseURL = s.URL
+
+	var err error
+	captureStdout(func() {
+		_, err = upgradeExecutable(context.Background(), gh, exe, "linux", "amd64")
+	})
+	if err == nil {
+		t.Errorf("upgradeExecutable should fail when the checksum does not match")
+	}
+	if b, _ := ioutil.ReadFile(exe); string(b) != "old binary" {
+		t.Errorf("the executable should not change after a failed upgrade, got %s", b)
+	}
+
+	_, err = upgradeExecutable(context.Background(), gh, exe, "plan9", "amd64")
+	if err == nil {
+		t.Errorf("upgradeExecutable should fail without an archive for the platform")
+	}
+}
+
+func Test_extractExecutable_zip(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, _ := zw.Create("glearn-cli/learn.exe")
+	w.Write([]byte("windows binary"))
+	zw.Close()
+
+	binary, err := extractExecutable(bytes.NewReader(archive.Bytes()), int64(archive.Len()), "glearn-cli_99.0.0_Windows_x86_64.zip", "windows")
+	if err != nil {
+		t.Fatalf("extractExecutable should find learn.exe, got %v", err)
+	}
+	defer binary.Close()
+	if b, _ := ioutil.ReadAll(binary); string(b) != "windows binary" {
+		t.Errorf("extractExecutable should read learn.exe, got %s", b)
+	}
+
+	if _, err := extractExecutable(bytes.NewReader(archive.Bytes()), int64(archive.Len()), "glearn-cli_99.0.0_Windows_x86_64.zip", "linux"); err == nil {
+		t.Errorf("extractExecutable should fail when the archive has no learn executable")
+	}
+}
+
+func Test_releaseAssetName(t *testing.T) {
+	tests := map[[2]string]string{
+		{"darwin", "amd64"}:  "glearn-cli_0.10.14_Darwin_x86_64.tar.gz",
+		{"darwin", "arm64"}:  "glearn-cli_0.10.14_Darwin_arm64.tar.gz",
+		{"linux", "386"}:     "glearn-cli_0.10.14_Linux_i386.tar.gz",
+		{"windows", "amd64"}: "glearn-cli_0.10.14_Windows_x86_64.zip",
+		{"freebsd", "amd64"}: "glearn-cli_0.10.14_freebsd_x86_64.tar.gz",
+	}
+	for platform, expected := range tests {
+		if got := releaseAssetName("v0.10.14", platform[0], platform[1]); got != expected {
+			t.Errorf("releaseAssetName for %v should be %s, got %s", platform, expected, got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/gSchool/glearn-cli/proxy_reader"
+)
+
+// uploadTimeout bounds the PUT of an archive
+const uploadTimeout = 180 * time.Second
+
+// uploadToS3 sends the archive at path to the presigned url in the appropriate bucket/key in one
+// PUT. The file is read as it is sent. The upload is abandoned when ctx is cancelled or it takes
+// longer than uploadTimeout.
+func uploadToS3(ctx context.Context, path string) error {
+	fmt.Println("Uploading assets to Learn...")
+
+	archive, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	info, err := archive.Stat()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, uploadTimeout)
+	defer cancel()
+
+	bar := startProgressBar(info.Size())
+	request, err := newArchiveUploadRequest(ctx, learn.API.Credentials.PresignedUrl, proxy_reader.New(archive, bar), info.Size())
+	if err != nil {
+		finishProgressBar(bar)
+		return err
+	}
+	resp, err := httpClient.Do(request)
+	finishProgressBar(bar)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Uploading asset produced non-200 status code: %d\n", resp.StatusCode)
+	}
+	printlnGreen("√")
+
+	return nil
+}
+
+// newArchiveUploadRequest creates the PUT of an archive of size bytes, read from body as it is sent
+func newArchiveUploadRequest(ctx context.Context, uri string, body io.Reader, size int64) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, "PUT", uri, body)
+	if err != nil {
+		return nil, err
+	}
+	request.ContentLength = size
+	return request, nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gSchool/glearn-cli/api/learn"
+	"github.com/gSchool/glearn-cli/api/learn/learntest"
+)
+
+func Test_uploadToS3(t *testing.T) {
+	defer func() { httpClient = &http.Client{} }()
+	s := learntest.NewServer()
+	defer s.Close()
+	var err error
+	learn.API, err = learn.NewAPI(context.Background(), s.URL, learntest.Token, s.Client(), true)
+	if err != nil {
+		t.Fatalf("NewAPI error: %s", err)
+	}
+	httpClient = s.Client()
+
+	var archive bytes.Buffer
+	writeArchive(context.Background(), &archive, archiveFixture(t), flate.BestSpeed)
+	path := filepath.Join(t.TempDir(), previewArchiveFile)
+	if err := ioutil.WriteFile(path, archive.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	captureStdout(func() {
+		err = uploadToS3(context.Background(), path)
+	})
+	if err != nil {
+		t.Fatalf("uploadToS3 errored: %s", err)
+	}
+	uploaded, ok := s.Upload(learn.API.Credentials.S3Key)
+	if !ok || !bytes.Equal(uploaded, archive.Bytes()) {
+		t.Errorf("the archive should be uploaded to the presigned url, got %d bytes of %d", len(uploaded), archive.Len())
+	}
+}
@@ -0,0 +1,107 @@
+package git
+
+import "fmt"
+
+// Fake is an in-memory Repo used for testing. The exported fields set the
+// answers each operation gives, while Added, Commits and Pushes record the
+// operations which would have modified a real repository.
+type Fake struct {
+	TopLevel string
+	Branch   string
+	// Remotes maps a remote name to its url
+	Remotes map[string]string
+	Dirty   bool
+	Ahead   int
+	Behind  int
+	// NoRemoteBranch makes AheadBehind report that the branch was never pushed
+	NoRemoteBranch bool
+	// Changed is the list of files ChangedFiles returns for any base
+	Changed []string
+	// Tracked is the list of files TrackedFiles returns
+	Tracked []string
+	// Errs maps an operation name, such as "Push", to the error it returns
+	Errs map[string]error
+
+	Added   []string
+	Commits []string
+	Pushes  []string
+
+	staged bool
+}
+
+// TopLevelDir returns TopLevel
+func (f *Fake) TopLevelDir() (string, error) {
+	return f.TopLevel, f.Errs["TopLevelDir"]
+}
+
+// CurrentBranch returns Branch
+func (f *Fake) CurrentBranch() (string, error) {
+	return f.Branch, f.Errs["CurrentBranch"]
+}
+
+// RemoteURL returns the url set for remote in Remotes
+func (f *Fake) RemoteURL(remote string) (string, error) {
+	if err := f.Errs["RemoteURL"]; err != nil {
+		return "", err
+	}
+	url, ok := f.Remotes[remote]
+	if !ok {
+		return "", fmt.Errorf("no such remote '%s'", remote)
+	}
+	return url, nil
+}
+
+// IsDirty returns Dirty
+func (f *Fake) IsDirty() (bool, error) {
+	return f.Dirty, f.Errs["IsDirty"]
+}
+
+// AheadBehind returns Ahead and Behind, or ErrNoRemoteBranch when NoRemoteBranch is set
+func (f *Fake) AheadBehind(remote, branch string) (int, int, error) {
+	if f.NoRemoteBranch {
+		return 0, 0, ErrNoRemoteBranch
+	}
+	return f.Ahead, f.Behind, f.Errs["AheadBehind"]
+}
+
+// ChangedFiles returns Changed
+func (f *Fake) ChangedFiles(base string) ([]string, error) {
+	return f.Changed, f.Errs["ChangedFiles"]
+}
+
+// TrackedFiles returns Tracked
+func (f *Fake) TrackedFiles() ([]string, error) {
+	return f.Tracked, f.Errs["TrackedFiles"]
+}
+
+// Add records the paths as staged
+func (f *Fake) Add(paths ...string) error {
+	if err := f.Errs["Add"]; err != nil {
+		return err
+	}
+	f.Added = append(f.Added, paths...)
+	f.staged = f.staged || len(paths) > 0
+	return nil
+}
+
+// Commit records the message, returning ErrNothingToCommit when nothing was added since the last commit
+func (f *Fake) Commit(message string) error {
+	if err := f.Errs["Commit"]; err != nil {
+		return err
+	}
+	if !f.staged {
+		return ErrNothingToCommit
+	}
+	f.Commits = append(f.Commits, message)
+	f.staged = false
+	return nil
+}
+
+// Push records the push as remote/branch
+func (f *Fake) Push(remote, branch string) error {
+	if err := f.Errs["Push"]; err != nil {
+		return err
+	}
+	f.Pushes = append(f.Pushes, remote+"/"+branch)
+	return nil
+}
@@ -0,0 +1,215 @@
+// Package git provides the small set of version control operations the learn
+// CLI needs when publishing curriculum. Repo is implemented by Native, which runs
+// the git executable directly, and by Fake, which is used in tests.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrNothingToCommit is returned from Commit when there are no staged changes
+var ErrNothingToCommit = errors.New("nothing to commit")
+
+// ErrNoRemoteBranch is returned from AheadBehind when the branch does not exist on the remote
+var ErrNoRemoteBranch = errors.New("branch does not exist on remote")
+
+// Repo describes the git operations used by the publish flow
+type Repo interface {
+	// TopLevelDir returns the absolute path of the root of the working tree
+	TopLevelDir() (string, error)
+	// CurrentBranch returns the short name of the checked out branch
+	CurrentBranch() (string, error)
+	// RemoteURL returns the push url for the named remote
+	RemoteURL(remote string) (string, error)
+	// IsDirty reports if tracked files have staged or unstaged changes
+	IsDirty() (bool, error)
+	// AheadBehind counts the commits the local branch has that the remote does not, and the reverse
+	AheadBehind(remote, branch string) (ahead int, behind int, err error)
+	// ChangedFiles lists the files added or modified on HEAD since it diverged from base
+	ChangedFiles(base string) ([]string, error)
+	// TrackedFiles lists the files committed or staged in the working tree
+	TrackedFiles() ([]string, error)
+	// Add stages the given paths
+	Add(paths ...string) error
+	// Commit records the staged changes with the given message
+	Commit(message string) error
+	// Push sends the branch to the remote
+	Push(remote, branch string) error
+}
+
+// Error is returned when the git executable fails, holding its combined output
+type Error struct {
+	Args   []string
+	Output string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("git %s: %s", strings.Join(e.Args, " "), strings.TrimSpace(e.Output))
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Native implements Repo by running the git executable without a shell. Only
+// plumbing and porcelain output meant for machines is parsed, so the results do
+// not depend on the user's locale or git version.
+type Native struct {
+	// Dir is the directory commands run in, the process working directory when empty
+	Dir string
+}
+
+// NewNative returns a Native repo rooted at dir
+func NewNative(dir string) *Native {
+	return &Native{Dir: dir}
+}
+
+// run executes git with args and returns its standard output as it is. Callers
+// reading a single value trim it, output split on NUL bytes is left whole.
+func (n *Native) run(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = n.Dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", &Error{Args: args, Output: stderr.String() + stdout.String(), Err: err}
+	}
+
+	return stdout.String(), nil
+}
+
+// runTrimmed executes git with args and returns its standard output without
+// surrounding whitespace, for commands printing a single value
+func (n *Native) runTrimmed(args ...string) (string, error) {
+	out, err := n.run(args...)
+	return strings.TrimSpace(out), err
+}
+
+// TopLevelDir returns the absolute path of the root of the working tree
+func (n *Native) TopLevelDir() (string, error) {
+	return n.runTrimmed("rev-parse", "--show-toplevel")
+}
+
+// CurrentBranch returns the short name of the checked out branch
+func (n *Native) CurrentBranch() (string, error) {
+	return n.runTrimmed("rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// RemoteURL returns the push url for the named remote
+func (n *Native) RemoteURL(remote string) (string, error) {
+	return n.runTrimmed("remote", "get-url", "--push", remote)
+}
+
+// IsDirty reports if tracked files have staged or unstaged changes. Untracked
+// files are not considered, as they are never published.
+func (n *Native) IsDirty() (bool, error) {
+	out, err := n.runTrimmed("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, err
+	}
+
+	return out != "", nil
+}
+
+// AheadBehind compares the local branch with the branch on the remote. The
+// remote is asked for the branch and it is fetched first, so commits pushed by
+// others since the last fetch are counted.
+func (n *Native) AheadBehind(remote, branch string) (int, int, error) {
+	// ls-remote exits with 2 when the remote has no matching ref
+	if _, err := n.run("ls-remote", "--exit-code", "--heads", remote, "refs/heads/"+branch); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 2 {
+			return 0, 0, ErrNoRemoteBranch
+		}
+		return 0, 0, err
+	}
+
+	remoteRef := fmt.Sprintf("refs/remotes/%s/%s", remote, branch)
+	if _, err := n.run("fetch", "--quiet", remote, fmt.Sprintf("+refs/heads/%s:%s", branch, remoteRef)); err != nil {
+		return 0, 0, err
+	}
+
+	out, err := n.runTrimmed("rev-list", "--left-right", "--count", fmt.Sprintf("refs/heads/%s...%s", branch, remoteRef))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	counts := strings.Fields(out)
+	if len(counts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", out)
+	}
+	ahead, err := strconv.Atoi(counts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	behind, err := strconv.Atoi(counts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return ahead, behind, nil
+}
+
+// ChangedFiles lists the files added, modified or renamed on HEAD since it
+// diverged from base, relative to the top level directory. Deleted files are
+// not listed. base must be available locally, e.g. fetched as origin/main.
+func (n *Native) ChangedFiles(base string) ([]string, error) {
+	out, err := n.run("diff", "--name-only", "-z", "--diff-filter=ACMR", base+"...HEAD", "--")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// TrackedFiles lists every file committed or staged, relative to the top level
+// directory, wherever in the working tree it is run from.
+func (n *Native) TrackedFiles() ([]string, error) {
+	out, err := n.run("ls-files", "-z", "--full-name", "--", ":/")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// splitNul splits the -z output of git into its paths
+func splitNul(out string) []string {
+	files := []string{}
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// Add stages the given paths
+func (n *Native) Add(paths ...string) error {
+	_, err := n.run(append([]string{"add", "--"}, paths...)...)
+	return err
+}
+
+// Commit records the staged changes with the given message. ErrNothingToCommit
+// is returned when nothing is staged.
+func (n *Native) Commit(message string) error {
+	// diff --quiet exits 1 when there are differences
+	if _, err := n.run("diff", "--cached", "--quiet"); err == nil {
+		return ErrNothingToCommit
+	}
+
+	_, err := n.run("commit", "-m", message)
+	return err
+}
+
+// Push sends the branch to the remote
+func (n *Native) Push(remote, branch string) error {
+	_, err := n.run("push", remote, branch)
+	return err
+}
@@ -0,0 +1,200 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// setupRepo creates a repository with one commit on main, pushed to a bare origin remote
+func setupRepo(t *testing.T) (*Native, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not available")
+	}
+
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	work := filepath.Join(root, "work")
+
+	t.Setenv("GIT_AUTHOR_NAME", "learn")
+	t.Setenv("GIT_AUTHOR_EMAIL", "learn@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "learn")
+	t.Setenv("GIT_COMMITTER_EMAIL", "learn@example.com")
+
+	gitCmd(t, root, "init", "--bare", remote)
+	gitCmd(t, root, "init", work)
+	gitCmd(t, work, "checkout", "-b", "main")
+	gitCmd(t, work, "remote", "add", "origin", remote)
+	writeFile(t, filepath.Join(work, "README.md"), "# hello\n")
+	gitCmd(t, work, "add", "README.md")
+	gitCmd(t, work, "commit", "-m", "initial")
+	gitCmd(t, work, "push", "origin", "main")
+
+	return NewNative(work), remote
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %s\n%s", args, err, out)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("could not write %s: %s", path, err)
+	}
+}
+
+func Test_NativeQueries(t *testing.T) {
+	repo, remote := setupRepo(t)
+
+	top, err := repo.TopLevelDir()
+	if err != nil {
+		t.Fatalf("TopLevelDir errored: %s", err)
+	}
+	want, _ := filepath.EvalSymlinks(repo.Dir)
+	got, _ := filepath.EvalSymlinks(top)
+	if got != want {
+		t.Errorf("TopLevelDir should be '%s' but was '%s'", want, got)
+	}
+
+	branch, err := repo.CurrentBranch()
+	if err != nil || branch != "main" {
+		t.Errorf("CurrentBranch should be 'main' but was '%s', err: %v", branch, err)
+	}
+
+	url, err := repo.RemoteURL("origin")
+	if err != nil || url != remote {
+		t.Errorf("RemoteURL should be '%s' but was '%s', err: %v", remote, url, err)
+	}
+
+	if _, err := repo.RemoteURL("upstream"); err == nil {
+		t.Errorf("RemoteURL should error for a remote that does not exist")
+	}
+}
+
+func Test_NativeDirtyAndAheadBehind(t *testing.T) {
+	repo, remote := setupRepo(t)
+
+	dirty, err := repo.IsDirty()
+	if err != nil || dirty {
+		t.Errorf("a freshly pushed repo should not be dirty, dirty: %v err: %v", dirty, err)
+	}
+
+	// untracked files are not publishable changes
+	writeFile(t, filepath.Join(repo.Dir, "scratch.txt"), "notes")
+	if dirty, _ := repo.IsDirty(); dirty {
+		t.Errorf("untracked files should not make the repo dirty")
+	}
+
+	writeFile(t, filepath.Join(repo.Dir, "README.md"), "# changed\n")
+	if dirty, _ := repo.IsDirty(); !dirty {
+		t.Errorf("modified tracked files should make the repo dirty")
+	}
+
+	ahead, behind, err := repo.AheadBehind("origin", "main")
+	if err != nil || ahead != 0 || behind != 0 {
+		t.Errorf("main should be even with origin, got ahead %d behind %d err %v", ahead, behind, err)
+	}
+
+	if err := repo.Add("README.md"); err != nil {
+		t.Fatalf("Add errored: %s", err)
+	}
+	if err := repo.Commit("change readme"); err != nil {
+		t.Fatalf("Commit errored: %s", err)
+	}
+
+	ahead, behind, err = repo.AheadBehind("origin", "main")
+	if err != nil || ahead != 1 || behind != 0 {
+		t.Errorf("main should be one ahead of origin, got ahead %d behind %d err %v", ahead, behind, err)
+	}
+
+	if err := repo.Push("origin", "main"); err != nil {
+		t.Fatalf("Push errored: %s", err)
+	}
+	ahead, _, _ = repo.AheadBehind("origin", "main")
+	if ahead != 0 {
+		t.Errorf("main should be even with origin after push, was ahead %d", ahead)
+	}
+
+	// another clone pushes to main, without a fetch in this one
+	other := filepath.Join(t.TempDir(), "other")
+	gitCmd(t, repo.Dir, "clone", "--branch", "main", remote, other)
+	writeFile(t, filepath.Join(other, "lesson.md"), "# lesson\n")
+	gitCmd(t, other, "add", "lesson.md")
+	gitCmd(t, other, "commit", "-m", "add a lesson")
+	gitCmd(t, other, "push", "origin", "main")
+
+	ahead, behind, err = repo.AheadBehind("origin", "main")
+	if err != nil || ahead != 0 || behind != 1 {
+		t.Errorf("main should be one behind origin once it was pushed to elsewhere, got ahead %d behind %d err %v", ahead, behind, err)
+	}
+
+	gitCmd(t, repo.Dir, "checkout", "-b", "feature")
+	if _, _, err := repo.AheadBehind("origin", "feature"); !errors.Is(err, ErrNoRemoteBranch) {
+		t.Errorf("an unpushed branch should return ErrNoRemoteBranch, got %v", err)
+	}
+}
+
+func Test_NativeCommitNothingStaged(t *testing.T) {
+	repo, _ := setupRepo(t)
+
+	if err := repo.Commit("empty"); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("Commit with nothing staged should return ErrNothingToCommit, got %v", err)
+	}
+}
+
+func Test_NativeChangedFiles(t *testing.T) {
+	repo, _ := setupRepo(t)
+
+	gitCmd(t, repo.Dir, "checkout", "-b", "feature")
+	if err := os.MkdirAll(filepath.Join(repo.Dir, "unit 1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(repo.Dir, "unit 1", "lesson.md"), "# lesson\n")
+	writeFile(t, filepath.Join(repo.Dir, "README.md"), "# changed\n")
+	gitCmd(t, repo.Dir, "add", ".")
+	gitCmd(t, repo.Dir, "commit", "-m", "add a lesson")
+	gitCmd(t, repo.Dir, "rm", "README.md")
+	gitCmd(t, repo.Dir, "commit", "-m", "remove the readme")
+
+	changed, err := repo.ChangedFiles("origin/main")
+	if err != nil {
+		t.Fatalf("ChangedFiles errored: %s", err)
+	}
+	if len(changed) != 1 || changed[0] != "unit 1/lesson.md" {
+		t.Errorf("ChangedFiles should list only the added lesson, got %q", changed)
+	}
+
+	if _, err := repo.ChangedFiles("origin/missing"); err == nil {
+		t.Errorf("ChangedFiles should error for a base which does not exist")
+	}
+}
+
+func Test_NativeTrackedFiles(t *testing.T) {
+	repo, _ := setupRepo(t)
+
+	if err := os.MkdirAll(filepath.Join(repo.Dir, "unit 1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(repo.Dir, "unit 1", "lesson.md"), "# lesson\n")
+	writeFile(t, filepath.Join(repo.Dir, "scratch.md"), "# not added\n")
+	writeFile(t, filepath.Join(repo.Dir, " notes.md"), "# starts with a space\n")
+	gitCmd(t, repo.Dir, "add", "unit 1", " notes.md")
+
+	// run from a subdirectory, paths are still relative to the top level
+	tracked, err := NewNative(filepath.Join(repo.Dir, "unit 1")).TrackedFiles()
+	if err != nil {
+		t.Fatalf("TrackedFiles errored: %s", err)
+	}
+	if len(tracked) != 3 || tracked[0] != " notes.md" || tracked[1] != "README.md" || tracked[2] != "unit 1/lesson.md" {
+		t.Errorf("TrackedFiles should list the committed and staged files, got %q", tracked)
+	}
+}
@@ -1,3 +1,5 @@
+// Package ignorematcher matches paths against the patterns of .gitignore and .dockerignore files,
+// one pattern at a time with IgnoreMatches or a whole ignore file with a Matcher.
 package ignorematcher
 
 import (
@@ -5,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"text/scanner"
 )
 
@@ -32,7 +35,6 @@ func regexpMatch(pattern, path string) (bool, error) {
 	if strings.HasSuffix(path, "/") {
 		path = Chop(path)
 	}
-	regStr := "^"
 
 	// Do some syntax checking on the pattern.
 	// filepath's Match() has some really weird rules that are inconsistent
@@ -44,12 +46,43 @@ func regexpMatch(pattern, path string) (bool, error) {
 		return false, err
 	}
 
+	re, err := compile(pattern, string(os.PathSeparator))
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(path), nil
+}
+
+// compiled caches the regexp of each pattern and separator, as the same patterns are matched
+// against every file in a directory
+var compiled sync.Map
+
+// compile returns the regexp for pattern with paths separated by sl, from the cache when it has
+// been compiled before
+func compile(pattern, sl string) (*regexp.Regexp, error) {
+	key := sl + "\x00" + pattern
+	if re, ok := compiled.Load(key); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(translate(pattern, sl))
+	if err != nil {
+		// Map regexp's error to filepath's so no one knows we're not using filepath
+		return nil, filepath.ErrBadPattern
+	}
+	compiled.Store(key, re)
+	return re, nil
+}
+
+// translate converts a pattern to a regexp matching whole paths separated by sl
+func translate(pattern, sl string) string {
+	regStr := "^"
+
 	// Go through the pattern and convert it to a regexp.
 	// We use a scanner so we can support utf-8 chars.
 	var scan scanner.Scanner
 	scan.Init(strings.NewReader(pattern))
 
-	sl := string(os.PathSeparator)
 	escSL := sl
 	if sl == `\` {
 		escSL += `\`
@@ -66,15 +99,15 @@ func regexpMatch(pattern, path string) (bool, error) {
 				if scan.Peek() == scanner.EOF {
 					// is "**EOF" - to align with .gitignore just accept all
 					regStr += ".*"
+				} else if string(scan.Peek()) == sl {
+					// is "**/", any number of directories including none.
+					// Treat **/ as ** so eat the "/"
+					scan.Next()
+					regStr += "(.*" + escSL + ")?"
 				} else {
 					// is "**"
 					regStr += "((.*" + escSL + ")|([^" + escSL + "]*))"
 				}
-
-				// Treat **/ as ** so eat the "/"
-				if string(scan.Peek()) == sl {
-					scan.Next()
-				}
 			} else {
 				// is "*" so map it to anything but "/"
 				regStr += "[^" + escSL + "]*"
@@ -82,10 +115,17 @@ func regexpMatch(pattern, path string) (bool, error) {
 		} else if ch == '?' {
 			// "?" is any char except "/"
 			regStr += "[^" + escSL + "]"
-		} else if strings.Contains(".$", string(ch)) {
+		} else if strings.Contains(".$+(){}|^", string(ch)) {
 			// Escape some regexp special chars that have no meaning
 			// in golang's filepath.Match
 			regStr += `\` + string(ch)
+		} else if ch == '[' {
+			// copy the character class, where "^" negates it as well as "!"
+			regStr += string(ch)
+			if scan.Peek() == '!' || scan.Peek() == '^' {
+				scan.Next()
+				regStr += "^"
+			}
 		} else if ch == '\\' {
 			// escape next char. Note that a trailing \ in the pattern
 			// will be left alone (but need to escape it)
@@ -106,14 +146,5 @@ func regexpMatch(pattern, path string) (bool, error) {
 		}
 	}
 
-	regStr += "$"
-
-	res, err := regexp.MatchString(regStr, path)
-
-	// Map regexp's error to filepath's so no one knows we're not using filepath
-	if err != nil {
-		err = filepath.ErrBadPattern
-	}
-
-	return res, err
+	return regStr + "$"
 }
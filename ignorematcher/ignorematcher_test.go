@@ -4,77 +4,85 @@ import (
 	"testing"
 )
 
+// matchTests are the conformance cases for a single pattern, shared by IgnoreMatches and Matcher
+var matchTests = []struct {
+	pattern string
+	text    string
+	pass    bool
+}{
+	{"**", "file", true},
+	{"**", "file/", true},
+	{"**/", "file", true}, // weird one
+	{"**/", "file/", true},
+	{"**", "/", true},
+	{"**/", "/", true},
+	{"**", "dir/file", true},
+	// {"**/", "dir/file", false}, //skipping this case because we added end of path and pattern removal of "/"
+	{"**", "dir/file/", true},
+	{"**/", "dir/file/", true},
+	{"**/**", "dir/file", true},
+	{"**/**", "dir/file/", true},
+	{"dir/**", "dir/file", true},
+	{"dir/**", "dir/file/", true},
+	{"dir", "dir/", true},
+	{"dir/", "dir", true},
+	{"dir/**", "dir/dir2/file", true},
+	{"dir/**", "dir/dir2/file/", true},
+	{"**/dir2/*", "dir/dir2/file", true},
+	// {"**/dir2/*", "dir/dir2/file/", false}, //skipping this case because we added end of path and pattern removal of "/"
+	{"**/dir2/**", "dir/dir2/dir3/file", true},
+	{"**/dir2/**", "dir/dir2/dir3/file/", true},
+	{"**file", "file", true},
+	{"**file", "dir/file", true},
+	{"**/file", "dir/file", true},
+	{"**file", "dir/dir/file", true},
+	{"**/file", "dir/dir/file", true},
+	{"**/file*", "dir/dir/file", true},
+	{"**/file*", "dir/dir/file.txt", true},
+	{"**/file*txt", "dir/dir/file.txt", true},
+	{"**/file*.txt", "dir/dir/file.txt", true},
+	{"**/file*.txt*", "dir/dir/file.txt", true},
+	{"**/**/*.txt", "dir/dir/file.txt", true},
+	{"**/**/*.txt2", "dir/dir/file.txt", false},
+	{"**/*.txt", "file.txt", true},
+	{"**/**/*.txt", "file.txt", true},
+	{"a**/*.txt", "a/file.txt", true},
+	{"a**/*.txt", "a/dir/file.txt", true},
+	{"a**/*.txt", "a/dir/dir/file.txt", true},
+	{"a/*.txt", "a/dir/file.txt", false},
+	{"a/*.txt", "a/file.txt", true},
+	{"a/*.txt**", "a/file.txt", true},
+	{"a[b-d]e", "ae", false},
+	{"a[b-d]e", "ace", true},
+	{"a[b-d]e", "aae", false},
+	{"a[^b-d]e", "aze", true},
+	{".*", ".foo", true},
+	{".*", "foo", false},
+	{"abc.def", "abcdef", false},
+	{"abc.def", "abc.def", true},
+	{"abc.def", "abcZdef", false},
+	{"abc?def", "abcZdef", true},
+	{"abc?def", "abcdef", false},
+	{"a\\*b", "a*b", true},
+	{"a\\", "a", false},
+	{"a\\", "a\\", false},
+	{"a\\\\", "a\\", true},
+	{"**/foo/bar", "foo/bar", true},
+	{"**/foo/bar", "dir/foo/bar", true},
+	{"**/foo/bar", "dir/dir2/foo/bar", true},
+	{"abc/**", "abc", false},
+	{"abc/**", "abc/def", true},
+	{"abc/**", "abc/def/ghi", true},
+	{"**/foo", "barfoo", false},
+	{"c++", "c++", true},
+	{"a(b)", "a(b)", true},
+	{"a{b,c}", "ab", false},
+	{"a[!b-d]e", "aze", true},
+	{"a[!b-d]e", "ace", false},
+}
+
 func TestMatches(t *testing.T) {
-	tests := []struct {
-		pattern string
-		text    string
-		pass    bool
-	}{
-		{"**", "file", true},
-		{"**", "file/", true},
-		{"**/", "file", true}, // weird one
-		{"**/", "file/", true},
-		{"**", "/", true},
-		{"**/", "/", true},
-		{"**", "dir/file", true},
-		// {"**/", "dir/file", false}, //skipping this case because we added end of path and pattern removal of "/"
-		{"**", "dir/file/", true},
-		{"**/", "dir/file/", true},
-		{"**/**", "dir/file", true},
-		{"**/**", "dir/file/", true},
-		{"dir/**", "dir/file", true},
-		{"dir/**", "dir/file/", true},
-		{"dir", "dir/", true},
-		{"dir/", "dir", true},
-		{"dir/**", "dir/dir2/file", true},
-		{"dir/**", "dir/dir2/file/", true},
-		{"**/dir2/*", "dir/dir2/file", true},
-		// {"**/dir2/*", "dir/dir2/file/", false}, //skipping this case because we added end of path and pattern removal of "/"
-		{"**/dir2/**", "dir/dir2/dir3/file", true},
-		{"**/dir2/**", "dir/dir2/dir3/file/", true},
-		{"**file", "file", true},
-		{"**file", "dir/file", true},
-		{"**/file", "dir/file", true},
-		{"**file", "dir/dir/file", true},
-		{"**/file", "dir/dir/file", true},
-		{"**/file*", "dir/dir/file", true},
-		{"**/file*", "dir/dir/file.txt", true},
-		{"**/file*txt", "dir/dir/file.txt", true},
-		{"**/file*.txt", "dir/dir/file.txt", true},
-		{"**/file*.txt*", "dir/dir/file.txt", true},
-		{"**/**/*.txt", "dir/dir/file.txt", true},
-		{"**/**/*.txt2", "dir/dir/file.txt", false},
-		{"**/*.txt", "file.txt", true},
-		{"**/**/*.txt", "file.txt", true},
-		{"a**/*.txt", "a/file.txt", true},
-		{"a**/*.txt", "a/dir/file.txt", true},
-		{"a**/*.txt", "a/dir/dir/file.txt", true},
-		{"a/*.txt", "a/dir/file.txt", false},
-		{"a/*.txt", "a/file.txt", true},
-		{"a/*.txt**", "a/file.txt", true},
-		{"a[b-d]e", "ae", false},
-		{"a[b-d]e", "ace", true},
-		{"a[b-d]e", "aae", false},
-		{"a[^b-d]e", "aze", true},
-		{".*", ".foo", true},
-		{".*", "foo", false},
-		{"abc.def", "abcdef", false},
-		{"abc.def", "abc.def", true},
-		{"abc.def", "abcZdef", false},
-		{"abc?def", "abcZdef", true},
-		{"abc?def", "abcdef", false},
-		{"a\\*b", "a*b", true},
-		{"a\\", "a", false},
-		{"a\\", "a\\", false},
-		{"a\\\\", "a\\", true},
-		{"**/foo/bar", "foo/bar", true},
-		{"**/foo/bar", "dir/foo/bar", true},
-		{"**/foo/bar", "dir/dir2/foo/bar", true},
-		{"abc/**", "abc", false},
-		{"abc/**", "abc/def", true},
-		{"abc/**", "abc/def/ghi", true},
-	}
-	for _, test := range tests {
+	for _, test := range matchTests {
 		res, _ := IgnoreMatches(test.pattern, test.text)
 		if res != test.pass {
 			t.Fatalf("Failed: %v - res:%v", test, res)
@@ -0,0 +1,237 @@
+package ignorematcher
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Syntax is the flavor of ignore file the patterns are written in
+type Syntax int
+
+const (
+	// Git patterns follow .gitignore. A pattern without a slash matches a name at any depth, a
+	// leading or middle slash anchors it to the ignore file's directory, a trailing slash matches
+	// only directories, and a file cannot be re-included when a directory above it is ignored.
+	Git Syntax = iota
+	// Docker patterns follow .dockerignore. Every pattern is relative to the root, a pattern
+	// matching a directory ignores everything in it, and a later ! pattern re-includes any path.
+	Docker
+)
+
+// Rule is one pattern of an ignore file
+type Rule struct {
+	// Pattern is the line as written in the ignore file
+	Pattern string
+	// Source and Line are where the rule was read from, Source is empty when it was not a file
+	Source string
+	Line   int
+
+	// glob is the pattern matched against paths relative to the root
+	glob    string
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// Negate reports if the rule re-includes the paths it matches, written with a leading !
+func (r *Rule) Negate() bool {
+	return r.negate
+}
+
+// String is the rule and where it was read from, e.g. .learnignore:3: data/*.csv
+func (r *Rule) String() string {
+	if r.Source == "" {
+		return r.Pattern
+	}
+	return fmt.Sprintf("%s:%d: %s", r.Source, r.Line, r.Pattern)
+}
+
+// matches reports if the rule's pattern matches p, a slash separated path relative to the root
+func (r *Rule) matches(p string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	return r.re.MatchString(p)
+}
+
+// Matcher is the ordered rules of an ignore file. The last rule matching a path decides if it is
+// ignored, so a later ! rule re-includes what an earlier rule ignored.
+type Matcher struct {
+	syntax Syntax
+	rules  []*Rule
+}
+
+// New compiles the lines of an ignore file. Blank lines and comments starting with # are skipped.
+func New(syntax Syntax, lines []string) (*Matcher, error) {
+	return compileRules(syntax, "", lines)
+}
+
+// Parse reads and compiles an ignore file from r, naming source in the rules
+func Parse(syntax Syntax, source string, r io.Reader) (*Matcher, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return compileRules(syntax, source, lines)
+}
+
+// ParseFile reads and compiles the ignore file at path. A missing file ignores nothing.
+func ParseFile(syntax Syntax, path string) (*Matcher, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &Matcher{syntax: syntax}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(syntax, path, f)
+}
+
+// compileRules turns each pattern line into a rule, erroring on the first invalid pattern
+func compileRules(syntax Syntax, source string, lines []string) (*Matcher, error) {
+	m := &Matcher{syntax: syntax}
+	for i, line := range lines {
+		rule, ok := parseRule(syntax, line)
+		if !ok {
+			continue
+		}
+		rule.Source, rule.Line = source, i+1
+
+		if _, err := filepath.Match(rule.glob, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern: %v", rule, err)
+		}
+		re, err := compile(rule.glob, "/")
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern: %v", rule, err)
+		}
+		rule.re = re
+		m.rules = append(m.rules, rule)
+	}
+	return m, nil
+}
+
+// parseRule reads one line of an ignore file into a rule to compile, ok is false for blank lines
+// and comments
+func parseRule(syntax Syntax, line string) (rule *Rule, ok bool) {
+	line = strings.TrimSuffix(line, "\r")
+	rule = &Rule{Pattern: strings.TrimSpace(line)}
+
+	if syntax == Docker {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			return nil, false
+		}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = strings.TrimSpace(line[1:])
+		}
+		line = strings.TrimPrefix(path.Clean(filepath.ToSlash(line)), "/")
+		if line == "." || line == "" {
+			return nil, false
+		}
+		rule.glob = line
+		return rule, true
+	}
+
+	// trailing spaces are ignored unless they are escaped with a backslash
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, false
+	}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, false
+	}
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+	rule.glob = line
+	return rule, true
+}
+
+// Rules returns the rules in the order they were read
+func (m *Matcher) Rules() []*Rule {
+	return m.rules
+}
+
+// HasExceptions reports if any rule re-includes paths with !, in which case the contents of an
+// ignored directory must still be checked with Docker syntax
+func (m *Matcher) HasExceptions() bool {
+	for _, r := range m.rules {
+		if r.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// Ignored reports if path, relative to the directory of the ignore file, is ignored
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	ignored, _ := m.Match(path, isDir)
+	return ignored
+}
+
+// Match reports if path, relative to the directory of the ignore file, is ignored, along with the
+// rule which decided it. The rule is nil when no rule matches the path.
+func (m *Matcher) Match(p string, isDir bool) (ignored bool, rule *Rule) {
+	p = strings.Trim(path.Clean("/"+filepath.ToSlash(p)), "/")
+	parents := []string{}
+	for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		parents = append([]string{dir}, parents...)
+	}
+
+	if m.syntax == Git {
+		// a file cannot be re-included when a directory above it is ignored
+		for _, dir := range parents {
+			if ignored, rule := m.last(dir, true); ignored {
+				return true, rule
+			}
+		}
+		return m.last(p, isDir)
+	}
+
+	for _, r := range m.rules {
+		matched := r.matches(p, isDir)
+		for _, dir := range parents {
+			matched = matched || r.matches(dir, true)
+		}
+		if matched {
+			ignored, rule = !r.negate, r
+		}
+	}
+	return ignored, rule
+}
+
+// last returns the decision of the last rule matching p itself
+func (m *Matcher) last(p string, isDir bool) (ignored bool, rule *Rule) {
+	for _, r := range m.rules {
+		if r.matches(p, isDir) {
+			ignored, rule = !r.negate, r
+		}
+	}
+	return ignored, rule
+}
@@ -0,0 +1,132 @@
+package ignorematcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ruleTests are the conformance cases for whole ignore files, each checked against the documented
+// behavior of .gitignore and .dockerignore
+var ruleTests = []struct {
+	name    string
+	syntax  Syntax
+	lines   []string
+	path    string
+	isDir   bool
+	ignored bool
+}{
+	{"comments are skipped", Git, []string{"# notes.md"}, "# notes.md", false, false},
+	{"escaped hash", Git, []string{`\#notes.md`}, "#notes.md", false, true},
+	{"escaped bang", Git, []string{`\!important.md`}, "!important.md", false, true},
+	{"blank lines are skipped", Git, []string{"", "   "}, "file", false, false},
+	{"trailing spaces are trimmed", Git, []string{"notes.md   "}, "notes.md", false, true},
+	{"escaped trailing space is kept", Git, []string{`notes\ `}, "notes ", false, true},
+	{"name matches at any depth", Git, []string{"*.csv"}, "data/big/set.csv", false, true},
+	{"leading slash anchors", Git, []string{"/build"}, "build", true, true},
+	{"leading slash anchors to the root only", Git, []string{"/build"}, "src/build", true, false},
+	{"middle slash anchors", Git, []string{"doc/*.txt"}, "doc/notes.txt", false, true},
+	{"middle slash does not match deeper", Git, []string{"doc/*.txt"}, "doc/server/notes.txt", false, false},
+	{"middle slash does not match below the root", Git, []string{"doc/*.txt"}, "src/doc/notes.txt", false, false},
+	{"directory only matches directories", Git, []string{"solutions/"}, "solutions", false, false},
+	{"directory only matches a directory", Git, []string{"solutions/"}, "unit-1/solutions", true, true},
+	{"directory only ignores its contents", Git, []string{"solutions/"}, "unit-1/solutions/answer.md", false, true},
+	{"leading double star", Git, []string{"**/foo"}, "foo", false, true},
+	{"leading double star in a directory", Git, []string{"**/foo"}, "a/b/foo", false, true},
+	{"leading double star needs a whole name", Git, []string{"**/foo"}, "a/barfoo", false, false},
+	{"trailing double star", Git, []string{"abc/**"}, "abc/d/e", false, true},
+	{"middle double star matches no directories", Git, []string{"a/**/b"}, "a/b", false, true},
+	{"middle double star matches directories", Git, []string{"a/**/b"}, "a/x/y/b", false, true},
+	{"negation re-includes", Git, []string{"*.log", "!keep.log"}, "logs/keep.log", false, false},
+	{"last match wins", Git, []string{"!keep.log", "*.log"}, "keep.log", false, true},
+	{"negation cannot re-include below an ignored directory", Git, []string{"logs/", "!logs/keep.log"}, "logs/keep.log", false, true},
+	{"negation re-includes below a directory's ignored contents", Git, []string{"logs/*", "!logs/keep.log"}, "logs/keep.log", false, false},
+
+	{"docker comments are skipped", Docker, []string{"# comment"}, "# comment", false, false},
+	{"docker patterns are anchored to the root", Docker, []string{"*.md"}, "README.md", false, true},
+	{"docker patterns do not match at any depth", Docker, []string{"*.md"}, "docs/README.md", false, false},
+	{"docker double star matches at any depth", Docker, []string{"**/*.md"}, "docs/README.md", false, true},
+	{"docker leading slash is the root", Docker, []string{"/tmp"}, "tmp", true, true},
+	{"docker patterns are cleaned", Docker, []string{"./data/../tmp/"}, "tmp", true, true},
+	{"docker directories ignore their contents", Docker, []string{"node_modules"}, "node_modules/a/b.js", false, true},
+	{"docker negation re-includes", Docker, []string{"*", "!Dockerfile"}, "Dockerfile", false, false},
+	{"docker negation leaves the rest ignored", Docker, []string{"*", "!Dockerfile"}, "test.sh", false, true},
+	{"docker negation re-includes below an ignored directory", Docker, []string{"data", "!data/small.csv"}, "data/small.csv", false, false},
+	{"docker last match wins", Docker, []string{"!data/small.csv", "data"}, "data/small.csv", false, true},
+}
+
+func TestMatcher(t *testing.T) {
+	for _, test := range ruleTests {
+		m, err := New(test.syntax, test.lines)
+		if err != nil {
+			t.Fatalf("%s: New errored: %v", test.name, err)
+		}
+		if ignored := m.Ignored(test.path, test.isDir); ignored != test.ignored {
+			t.Errorf("%s: %q ignoring %q should be %v, got %v", test.name, test.lines, test.path, test.ignored, ignored)
+		}
+	}
+}
+
+// TestMatcherPatterns checks a Matcher of one Docker rule, which is anchored to the root like a
+// pattern given to IgnoreMatches, agrees with every single pattern case
+func TestMatcherPatterns(t *testing.T) {
+	for _, test := range matchTests {
+		m, err := New(Docker, []string{test.pattern})
+		if err != nil {
+			if test.pass {
+				t.Errorf("Failed: %v - New errored: %v", test, err)
+			}
+			continue
+		}
+		isDir := strings.HasSuffix(test.text, "/")
+		if res := m.Ignored(test.text, isDir); res != test.pass {
+			t.Errorf("Failed: %v - res:%v", test, res)
+		}
+	}
+}
+
+func TestMatcherMatch(t *testing.T) {
+	m, err := Parse(Git, ".learnignore", strings.NewReader("# big files\n*.csv\n!small.csv\n"))
+	if err != nil {
+		t.Fatalf("Parse errored: %v", err)
+	}
+
+	ignored, rule := m.Match("data/big.csv", false)
+	if !ignored || rule == nil || rule.String() != ".learnignore:2: *.csv" {
+		t.Errorf("Match should return the rule which ignored the file, got %v %v", ignored, rule)
+	}
+	ignored, rule = m.Match("data/small.csv", false)
+	if ignored || rule == nil || !rule.Negate() || rule.Line != 3 {
+		t.Errorf("Match should return the rule which re-included the file, got %v %v", ignored, rule)
+	}
+	if ignored, rule := m.Match("README.md", false); ignored || rule != nil {
+		t.Errorf("Match should return no rule when none match, got %v %v", ignored, rule)
+	}
+	if !m.HasExceptions() || len(m.Rules()) != 2 {
+		t.Errorf("the comment should be skipped and the negation found, got %d rules", len(m.Rules()))
+	}
+
+	if _, err := New(Git, []string{"ok", "a[b"}); err == nil || !strings.Contains(err.Error(), "a[b") {
+		t.Errorf("New should error on an invalid pattern, got %v", err)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	m, err := ParseFile(Docker, filepath.Join(t.TempDir(), ".dockerignore"))
+	if err != nil || len(m.Rules()) != 0 {
+		t.Errorf("a missing ignore file should ignore nothing, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), ".dockerignore")
+	if err := os.WriteFile(path, []byte("*.png\r\n!keep.png\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err = ParseFile(Docker, path)
+	if err != nil {
+		t.Fatalf("ParseFile errored: %v", err)
+	}
+	if !m.Ignored("image.png", false) || m.Ignored("keep.png", false) {
+		t.Errorf("ParseFile should read every line, including windows line endings")
+	}
+}
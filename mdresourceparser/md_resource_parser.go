@@ -151,8 +151,6 @@ func (p *MDResourceParser) extractPath() error {
 	default:
 		return fmt.Errorf("no match")
 	}
-
-	return nil
 }
 
 // matchError is used by the parser to determine if a rune equas the current character, and protects against EOF
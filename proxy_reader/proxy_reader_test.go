@@ -0,0 +1,24 @@
+package proxy_reader
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func Test_ProxyReader(t *testing.T) {
+	bar := NewBar(20, ioutil.Discard)
+	pr := New(strings.NewReader("0123456789"), bar)
+
+	b, err := ioutil.ReadAll(pr)
+	if err != nil || string(b) != "0123456789" {
+		t.Errorf("reading should return the body, got %q and %v", b, err)
+	}
+	if bar.Current() != 10 {
+		t.Errorf("the bar should count each byte read once, got %d", bar.Current())
+	}
+
+	if b, _ := ioutil.ReadAll(New(strings.NewReader("abc"), nil)); string(b) != "abc" {
+		t.Errorf("a reader without a bar should still return the body, got %q", b)
+	}
+}